@@ -0,0 +1,16 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+const chaosSupported = false
+
+// pauseProcess 当前平台不支持 SIGSTOP
+func pauseProcess(*os.Process, time.Duration) error {
+	return errors.New("当前平台不支持暂停进程")
+}
@@ -0,0 +1,20 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+const chaosSupported = true
+
+// pauseProcess 用 SIGSTOP 暂停进程 d 后再用 SIGCONT 恢复，模拟长时间 GC 停顿或虚拟机被挂起
+func pauseProcess(p *os.Process, d time.Duration) error {
+	if err := p.Signal(syscall.SIGSTOP); err != nil {
+		return err
+	}
+	time.Sleep(d)
+	return p.Signal(syscall.SIGCONT)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// 事件类型
+const (
+	eventEnter = "enter" // 获取锁，进入临界区
+	eventWrite = "write" // 在临界区内对受保护资源做一次带 fencing token 的写入
+	eventExit  = "exit"  // 离开临界区（记录于释放锁之前）
+)
+
+// Event 共享日志中的一条记录，每行一个 JSON
+type Event struct {
+	Worker   int    `json:"worker"`
+	Kind     string `json:"kind"`
+	Token    int64  `json:"token"`
+	Time     int64  `json:"time"`               // UnixNano
+	Lost     bool   `json:"lost,omitempty"`     // exit：释放时发现锁已丢失
+	Accepted bool   `json:"accepted,omitempty"` // write：写入被资源接受
+	Seq      int64  `json:"seq,omitempty"`      // write：资源上的写入序号（Redis 执行顺序）
+}
+
+// section 一次临界区
+type section struct {
+	worker     int
+	token      int64
+	start, end int64
+	lost       bool
+	complete   bool
+}
+
+// Report 检查结果
+type Report struct {
+	Workers    int
+	Sections   int   // 临界区数量
+	Incomplete int   // 没有 exit 记录的临界区（进程异常退出）
+	MaxToken   int64 // 最大 fencing token
+
+	Overlaps         int // 临界区重叠次数
+	DetectedOverlaps int // 其中先进入的持有者在释放时感知到了锁丢失
+
+	Writes, Accepted, Rejected int
+	OrderViolations            int // 被接受的写入中 fencing token 回退的次数
+
+	Violations []string // 违反安全性的详情
+}
+
+// OK 是否满足安全性：没有未被感知的重叠，资源历史按 fencing token 单调
+func (r *Report) OK() bool {
+	return r.Overlaps == r.DetectedOverlaps && r.OrderViolations == 0
+}
+
+// Check 检查共享日志：
+//  1. 互斥：任意两个临界区不重叠；持有者被暂停导致锁过期时会重叠，但持有者必须在释放时感知到锁丢失
+//  2. 线性一致：资源按 Redis 执行顺序接受的写入，其 fencing token 单调不减，过期持有者的写入被拒绝
+func Check(events []Event) *Report {
+	r := &Report{}
+
+	type sectionKey struct {
+		worker int
+		token  int64
+	}
+	sections := make(map[sectionKey]*section)
+	workers := make(map[int]bool)
+	var writes []Event
+	for _, e := range events {
+		workers[e.Worker] = true
+		if e.Token > r.MaxToken {
+			r.MaxToken = e.Token
+		}
+		k := sectionKey{e.Worker, e.Token}
+		switch e.Kind {
+		case eventEnter:
+			sections[k] = &section{worker: e.Worker, token: e.Token, start: e.Time}
+		case eventExit:
+			if s, ok := sections[k]; ok {
+				s.end, s.lost, s.complete = e.Time, e.Lost, true
+			}
+		case eventWrite:
+			r.Writes++
+			if e.Accepted {
+				r.Accepted++
+				writes = append(writes, e)
+			} else {
+				r.Rejected++
+			}
+		}
+	}
+	r.Workers = len(workers)
+
+	list := make([]*section, 0, len(sections))
+	var last int64
+	for _, s := range sections {
+		list = append(list, s)
+		if s.end > last {
+			last = s.end
+		}
+	}
+	for _, s := range list {
+		if !s.complete {
+			// 没有 exit 记录时视为一直持有到日志结束
+			r.Incomplete++
+			s.end = last
+		}
+	}
+	r.Sections = len(list)
+
+	sort.Slice(list, func(i, j int) bool { return list[i].start < list[j].start })
+	var prev *section // 目前为止结束最晚的临界区
+	for _, s := range list {
+		if prev != nil && s.start < prev.end {
+			r.Overlaps++
+			if prev.lost {
+				r.DetectedOverlaps++
+			} else {
+				r.Violations = append(r.Violations, fmt.Sprintf(
+					"worker %d (token %d) 与 worker %d (token %d) 的临界区重叠 %.1fms，且前者未感知锁丢失",
+					prev.worker, prev.token, s.worker, s.token, float64(prev.end-s.start)/1e6))
+			}
+		}
+		if prev == nil || s.end > prev.end {
+			prev = s
+		}
+	}
+
+	sort.Slice(writes, func(i, j int) bool { return writes[i].Seq < writes[j].Seq })
+	for i := 1; i < len(writes); i++ {
+		if writes[i].Token < writes[i-1].Token {
+			r.OrderViolations++
+			r.Violations = append(r.Violations, fmt.Sprintf(
+				"资源写入 #%d 的 fencing token %d 小于之前接受的 %d",
+				writes[i].Seq, writes[i].Token, writes[i-1].Token))
+		}
+	}
+	return r
+}
+
+// Print 输出报告
+func (r *Report) Print(w io.Writer) {
+	fmt.Fprintln(w, "=== lockbench 报告 ===")
+	fmt.Fprintf(w, "进入临界区的 worker: %d\n", r.Workers)
+	fmt.Fprintf(w, "临界区:             %d (未完成 %d)\n", r.Sections, r.Incomplete)
+	fmt.Fprintf(w, "最大 fencing token: %d\n", r.MaxToken)
+	fmt.Fprintf(w, "临界区重叠:         %d (持有者已感知锁丢失 %d)\n", r.Overlaps, r.DetectedOverlaps)
+	fmt.Fprintf(w, "资源写入:           %d (接受 %d，拒绝过期 token %d)\n", r.Writes, r.Accepted, r.Rejected)
+	fmt.Fprintf(w, "token 回退:         %d\n", r.OrderViolations)
+	for _, v := range r.Violations {
+		fmt.Fprintln(w, "  违反:", v)
+	}
+	if r.OK() {
+		fmt.Fprintln(w, "结论: 通过")
+	} else {
+		fmt.Fprintln(w, "结论: 失败")
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+func TestCheckNoOverlap(t *testing.T) {
+	events := []Event{
+		{Worker: 1, Kind: eventEnter, Token: 1, Time: 100},
+		{Worker: 1, Kind: eventWrite, Token: 1, Time: 150, Accepted: true, Seq: 1},
+		{Worker: 1, Kind: eventExit, Token: 1, Time: 200},
+		{Worker: 2, Kind: eventEnter, Token: 2, Time: 210},
+		{Worker: 2, Kind: eventWrite, Token: 2, Time: 250, Accepted: true, Seq: 2},
+		{Worker: 2, Kind: eventExit, Token: 2, Time: 300},
+	}
+	r := Check(events)
+	if !r.OK() || r.Sections != 2 || r.Workers != 2 || r.MaxToken != 2 || r.Overlaps != 0 {
+		t.Errorf("没有重叠时应通过: %+v", r)
+	}
+}
+
+func TestCheckDetectedOverlap(t *testing.T) {
+	// worker 1 被暂停导致锁过期，worker 2 获取锁；worker 1 恢复后写入被拒绝，释放时感知到锁丢失
+	events := []Event{
+		{Worker: 1, Kind: eventEnter, Token: 1, Time: 100},
+		{Worker: 2, Kind: eventEnter, Token: 2, Time: 400},
+		{Worker: 2, Kind: eventWrite, Token: 2, Time: 450, Accepted: true, Seq: 1},
+		{Worker: 1, Kind: eventWrite, Token: 1, Time: 500},
+		{Worker: 2, Kind: eventExit, Token: 2, Time: 550},
+		{Worker: 1, Kind: eventExit, Token: 1, Time: 600, Lost: true},
+	}
+	r := Check(events)
+	if !r.OK() || r.Overlaps != 1 || r.DetectedOverlaps != 1 || r.Rejected != 1 {
+		t.Errorf("持有者感知锁丢失且写入被拒绝时应通过: %+v", r)
+	}
+}
+
+func TestCheckViolations(t *testing.T) {
+	events := []Event{
+		{Worker: 1, Kind: eventEnter, Token: 1, Time: 100},
+		{Worker: 2, Kind: eventEnter, Token: 2, Time: 150},
+		{Worker: 2, Kind: eventWrite, Token: 2, Time: 160, Accepted: true, Seq: 1},
+		{Worker: 1, Kind: eventWrite, Token: 1, Time: 170, Accepted: true, Seq: 2},
+		{Worker: 1, Kind: eventExit, Token: 1, Time: 200},
+		{Worker: 2, Kind: eventExit, Token: 2, Time: 250},
+		{Worker: 3, Kind: eventEnter, Token: 3, Time: 300}, // 没有 exit，视为持有到日志结束
+	}
+	r := Check(events)
+	if r.OK() {
+		t.Fatal("未感知的重叠和 token 回退应判定失败")
+	}
+	if r.Overlaps != 1 || r.DetectedOverlaps != 0 || r.OrderViolations != 1 || r.Incomplete != 1 {
+		t.Errorf("检查结果不正确: %+v", r)
+	}
+	if len(r.Violations) != 2 {
+		t.Errorf("应记录 2 条违反详情，实际: %v", r.Violations)
+	}
+}
@@ -0,0 +1,183 @@
+// lockbench 多进程分布式锁混沌测试
+//
+// 启动 N 个 worker 进程争抢同一把锁，临界区内随机停顿，并随机用 SIGSTOP 暂停 worker 进程
+// 模拟 GC 停顿；每次进入和离开临界区都记录到共享日志，结束后检查临界区是否重叠、
+// 受保护资源的写入历史是否按 fencing token 线性一致，并输出报告。
+//
+// 用法：
+//
+//	lockbench [连接参数] [-workers 5] [-duration 20s] [-ttl 1s] [-stop-prob 0.2] ...
+//
+// 连接参数见 goRedisLock/config。存在违反安全性的情况时以状态码 1 退出
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"goRedisLock/config"
+)
+
+func main() {
+	var cfg benchConfig
+	workers := flag.Int("workers", 5, "worker 进程数")
+	workerID := flag.Int("worker", 0, "内部使用：以 worker 进程运行时的编号")
+	flag.StringVar(&cfg.key, "key", "lockbench", "争抢的锁 key")
+	flag.DurationVar(&cfg.ttl, "ttl", time.Second, "锁过期时间")
+	flag.DurationVar(&cfg.duration, "duration", 20*time.Second, "测试时长")
+	flag.DurationVar(&cfg.hold, "hold", 20*time.Millisecond, "临界区内的最长业务耗时")
+	flag.Float64Var(&cfg.pauseProb, "pause-prob", 0.02, "临界区内停顿的概率")
+	flag.DurationVar(&cfg.pause, "pause", 0, "临界区内停顿的时长，默认 2 倍 TTL")
+	flag.BoolVar(&cfg.autoRenew, "auto-renew", true, "持有锁期间由看门狗自动续期")
+	flag.StringVar(&cfg.logPath, "log", filepath.Join(os.TempDir(), "lockbench.log"), "共享事件日志路径")
+	stopProb := flag.Float64("stop-prob", 0.2, "每个混沌周期暂停一个 worker 进程的概率")
+	stopEvery := flag.Duration("stop-every", time.Second, "混沌周期")
+	stopFor := flag.Duration("stop-for", 0, "暂停 worker 进程的时长，默认 2 倍 TTL")
+	redisCfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal("加载Redis配置失败: ", err)
+	}
+	if cfg.pause <= 0 {
+		cfg.pause = 2 * cfg.ttl
+	}
+	if *stopFor <= 0 {
+		*stopFor = 2 * cfg.ttl
+	}
+
+	rdb, err := redisCfg.NewClient()
+	if err != nil {
+		log.Fatal("创建Redis客户端失败: ", err)
+	}
+	defer rdb.Close()
+	ctx := context.Background()
+
+	if *workerID > 0 {
+		if err := runWorker(ctx, rdb, *workerID, cfg); err != nil {
+			log.Fatalf("worker %d: %v", *workerID, err)
+		}
+		return
+	}
+
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		log.Fatalf("Redis连接失败，请确保Redis服务在 %s 运行: %v", redisCfg.Addr(), err)
+	}
+	// 清理上一次测试留下的锁、资源和日志
+	if err := rdb.Del(ctx, cfg.key, resourceKey(cfg.key)).Err(); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.Truncate(cfg.logPath, 0); err != nil && !os.IsNotExist(err) {
+		log.Fatal(err)
+	}
+
+	procs, err := spawnWorkers(*workers)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("已启动 %d 个 worker 进程，测试 %v，事件日志: %s", len(procs), cfg.duration, cfg.logPath)
+
+	stop := make(chan struct{})
+	var chaos sync.WaitGroup
+	if *stopProb > 0 {
+		if chaosSupported {
+			chaos.Add(1)
+			go func() {
+				defer chaos.Done()
+				runChaos(procs, *stopProb, *stopEvery, *stopFor, stop)
+			}()
+		} else {
+			log.Print("当前平台不支持 SIGSTOP，跳过暂停 worker 进程")
+		}
+	}
+
+	failed := 0
+	for i, p := range procs {
+		if err := p.Wait(); err != nil {
+			log.Printf("worker %d 异常退出: %v", i+1, err)
+			failed++
+		}
+	}
+	close(stop)
+	chaos.Wait()
+
+	events, err := readEvents(cfg.logPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	report := Check(events)
+	report.Print(os.Stdout)
+	if failed > 0 || !report.OK() {
+		os.Exit(1)
+	}
+}
+
+// spawnWorkers 以 worker 模式重新启动当前程序，参数与环境变量原样传递
+func spawnWorkers(n int) ([]*exec.Cmd, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	procs := make([]*exec.Cmd, n)
+	for i := range procs {
+		args := append([]string{"-worker", strconv.Itoa(i + 1)}, os.Args[1:]...)
+		cmd := exec.Command(self, args...)
+		cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+		if err := cmd.Start(); err != nil {
+			return nil, fmt.Errorf("启动 worker %d 失败: %w", i+1, err)
+		}
+		procs[i] = cmd
+	}
+	return procs, nil
+}
+
+// runChaos 每个周期以 prob 的概率随机暂停一个 worker 进程，直到 stop 关闭
+func runChaos(procs []*exec.Cmd, prob float64, every, pause time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		if rand.Float64() >= prob {
+			continue
+		}
+		i := rand.Intn(len(procs))
+		log.Printf("暂停 worker %d %v", i+1, pause)
+		if err := pauseProcess(procs[i].Process, pause); err != nil {
+			// 进程可能已经退出
+			log.Printf("暂停 worker %d 失败: %v", i+1, err)
+		}
+	}
+}
+
+// readEvents 读取共享日志
+func readEvents(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("解析事件日志失败: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, scanner.Err()
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"goRedisLock/lock"
+)
+
+// 带 fencing token 的资源写入：token 不小于已接受的最大 token 时写入成功，
+// 返回资源上的写入序号（即 Redis 的执行顺序），被拒绝时返回 0
+var fencedWriteScript = redis.NewScript(`
+	local current = tonumber(redis.call("hget", KEYS[1], "token") or "0")
+	if tonumber(ARGV[1]) < current then
+		return 0
+	end
+	redis.call("hset", KEYS[1], "token", ARGV[1], "writer", ARGV[2])
+	return redis.call("hincrby", KEYS[1], "seq", 1)
+`)
+
+// benchConfig 压测参数，协调进程通过命令行原样传给 worker 进程
+type benchConfig struct {
+	key       string
+	ttl       time.Duration
+	duration  time.Duration
+	hold      time.Duration // 临界区内的最长业务耗时
+	pauseProb float64       // 临界区内停顿的概率
+	pause     time.Duration // 临界区内停顿的时长
+	autoRenew bool
+	logPath   string
+}
+
+// resourceKey 受锁保护的资源
+func resourceKey(key string) string {
+	return key + ":resource"
+}
+
+// recorder 向共享日志追加事件
+type recorder struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+func openRecorder(path string) (*recorder, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &recorder{f: f}, nil
+}
+
+// record 写入一行事件；O_APPEND 保证多个进程的单次写入不会互相覆盖
+func (r *recorder) record(e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.f.Write(append(data, '\n'))
+	return err
+}
+
+func (r *recorder) Close() error {
+	return r.f.Close()
+}
+
+// runWorker worker 进程：在 duration 内反复争抢锁，记录每次进入和离开临界区
+func runWorker(ctx context.Context, rdb redis.UniversalClient, id int, cfg benchConfig) error {
+	rec, err := openRecorder(cfg.logPath)
+	if err != nil {
+		return err
+	}
+	defer rec.Close()
+
+	locks := lock.NewClient(rdb)
+	deadline := time.Now().Add(cfg.duration)
+	for time.Now().Before(deadline) {
+		l := locks.NewLock(cfg.key, &lock.Options{
+			TTL:       cfg.ttl,
+			Value:     fmt.Sprintf("worker_%d_%d", id, os.Getpid()),
+			Retry:     lock.ExponentialBackoff(5*time.Millisecond, 100*time.Millisecond),
+			AutoRenew: cfg.autoRenew,
+		})
+		err := holdOnce(ctx, rdb, rec, l, id, deadline, cfg)
+		if errors.Is(err, lock.ErrNotObtained) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// holdOnce 获取一次锁并执行临界区。获取锁的 ctx 在释放之后才取消，
+// 否则 -auto-renew 开启的看门狗会随之停止续期
+func holdOnce(ctx context.Context, rdb redis.UniversalClient, rec *recorder, l *lock.RedisLock, id int, deadline time.Time, cfg benchConfig) error {
+	waitCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+	if err := l.Lock(waitCtx); err != nil {
+		return err
+	}
+	// 进入时间取在获取成功之后，离开时间取在释放之前，保证记录的区间不大于实际持有锁的区间
+	token := l.Token()
+	if err := rec.record(Event{Worker: id, Kind: eventEnter, Token: token, Time: time.Now().UnixNano()}); err != nil {
+		return err
+	}
+
+	if err := criticalSection(ctx, rdb, rec, id, token, cfg); err != nil {
+		return err
+	}
+
+	exit := time.Now().UnixNano()
+	err := l.Unlock(ctx)
+	lost := errors.Is(err, lock.ErrLockNotHeld)
+	if err != nil && !lost {
+		return err
+	}
+	return rec.record(Event{Worker: id, Kind: eventExit, Token: token, Time: exit, Lost: lost})
+}
+
+// criticalSection 模拟业务：随机耗时，偶尔停顿超过 TTL，最后写入受保护的资源
+func criticalSection(ctx context.Context, rdb redis.UniversalClient, rec *recorder, id int, token int64, cfg benchConfig) error {
+	if cfg.hold > 0 {
+		time.Sleep(time.Duration(rand.Int63n(int64(cfg.hold))))
+	}
+	if rand.Float64() < cfg.pauseProb {
+		time.Sleep(cfg.pause)
+	}
+
+	seq, err := fencedWriteScript.Run(ctx, rdb, []string{resourceKey(cfg.key)}, token, id).Int64()
+	if err != nil {
+		return err
+	}
+	return rec.record(Event{Worker: id, Kind: eventWrite, Token: token, Time: time.Now().UnixNano(), Accepted: seq > 0, Seq: seq})
+}
@@ -0,0 +1,235 @@
+// lockctl 查看和强制释放分布式锁的运维工具
+//
+// 用法：
+//
+//	lockctl [连接参数] list [-prefix 前缀]
+//	lockctl [连接参数] show <key>
+//	lockctl [连接参数] release -operator <操作人> -reason <原因> <key>
+//	lockctl [连接参数] audit [-n 条数]
+//
+// 连接参数见 goRedisLock/config，也可以通过 REDIS_ 前缀的环境变量或 -redis-config 配置文件指定
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"goRedisLock/config"
+	"goRedisLock/lock"
+)
+
+// AuditRecord 强制释放的审计记录
+type AuditRecord struct {
+	Time     time.Time `json:"time"`
+	Operator string    `json:"operator"`
+	Key      string    `json:"key"`
+	Owners   []string  `json:"owners"`
+	Token    int64     `json:"fence_token"`
+	Reason   string    `json:"reason"`
+}
+
+func main() {
+	auditKey := flag.String("audit-key", "lockctl:audit", "保存审计记录的Redis列表")
+	auditMax := flag.Int64("audit-max", 1000, "审计记录最多保留的条数，超出时丢弃最早的记录")
+	flag.Usage = usage
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal("加载Redis配置失败: ", err)
+	}
+
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	rdb, err := cfg.NewClient()
+	if err != nil {
+		log.Fatal("创建Redis客户端失败: ", err)
+	}
+	defer rdb.Close()
+
+	ctx := context.Background()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		log.Fatal("Redis连接失败: ", err)
+	}
+
+	cmd, args := flag.Arg(0), flag.Args()[1:]
+	switch cmd {
+	case "list":
+		err = runList(ctx, rdb, args)
+	case "show":
+		err = runShow(ctx, rdb, args)
+	case "release":
+		err = runRelease(ctx, rdb, *auditKey, *auditMax, args)
+	case "audit":
+		err = runAudit(ctx, rdb, *auditKey, args)
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `用法: lockctl [连接参数] <命令> [参数]
+
+命令:
+  list     列出前缀下的锁
+  show     查看单个锁的详情
+  release  强制释放锁（记录审计日志）
+  audit    查看强制释放的审计记录
+
+连接参数:`)
+	flag.PrintDefaults()
+}
+
+// 列出前缀下的锁
+func runList(ctx context.Context, rdb redis.UniversalClient, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	prefix := fs.String("prefix", "", "锁key前缀")
+	fs.Parse(args)
+
+	infos, err := lock.List(ctx, rdb, *prefix)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tKIND\tOWNER\tTTL\tFENCE\tWAITERS")
+	for _, info := range infos {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\n",
+			info.Key, info.Kind, formatOwners(info.Owners), formatTTL(info.TTL), info.FenceToken, info.Waiters)
+	}
+	return w.Flush()
+}
+
+// 查看单个锁的详情
+func runShow(ctx context.Context, rdb redis.UniversalClient, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("用法: lockctl show <key>")
+	}
+	info, err := lock.Inspect(ctx, rdb, args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("key:         %s\n", info.Key)
+	fmt.Printf("类型:        %s\n", info.Kind)
+	fmt.Printf("持有者:      %s\n", formatOwners(info.Owners))
+	fmt.Printf("剩余时间:    %s\n", formatTTL(info.TTL))
+	fmt.Printf("fence token: %d\n", info.FenceToken)
+	fmt.Printf("等待者:      %d\n", info.Waiters)
+	return nil
+}
+
+// 强制释放锁，并记录审计日志
+func runRelease(ctx context.Context, rdb redis.UniversalClient, auditKey string, auditMax int64, args []string) error {
+	fs := flag.NewFlagSet("release", flag.ExitOnError)
+	operator := fs.String("operator", currentUser(), "操作人")
+	reason := fs.String("reason", "", "强制释放的原因（必填）")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("用法: lockctl release -operator <操作人> -reason <原因> <key>")
+	}
+	if *reason == "" {
+		return fmt.Errorf("强制释放必须通过 -reason 说明原因")
+	}
+	key := fs.Arg(0)
+
+	before, err := lock.ForceRelease(ctx, rdb, key)
+	if err != nil {
+		return err
+	}
+	if before.Kind == "unknown" {
+		return fmt.Errorf("%s 不是锁，未做修改", key)
+	}
+
+	record := AuditRecord{
+		Time:     time.Now(),
+		Operator: *operator,
+		Key:      key,
+		Owners:   before.Owners,
+		Token:    before.FenceToken,
+		Reason:   *reason,
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, auditKey, data)
+		if auditMax > 0 {
+			pipe.LTrim(ctx, auditKey, 0, auditMax-1)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("锁已释放，但写入审计记录失败: %w", err)
+	}
+
+	log.Printf("审计: %s 强制释放锁 %s (持有者: %s, fence token: %d, 原因: %s)",
+		record.Operator, key, formatOwners(record.Owners), record.Token, record.Reason)
+	return nil
+}
+
+// 查看最近的审计记录
+func runAudit(ctx context.Context, rdb redis.UniversalClient, auditKey string, args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	n := fs.Int64("n", 20, "显示的记录条数")
+	fs.Parse(args)
+
+	items, err := rdb.LRange(ctx, auditKey, 0, *n-1).Result()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tOPERATOR\tKEY\tOWNER\tFENCE\tREASON")
+	for _, item := range items {
+		var r AuditRecord
+		if err := json.Unmarshal([]byte(item), &r); err != nil {
+			return fmt.Errorf("解析审计记录失败: %w", err)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n",
+			r.Time.Format(time.DateTime), r.Operator, r.Key, formatOwners(r.Owners), r.Token, r.Reason)
+	}
+	return w.Flush()
+}
+
+func formatOwners(owners []string) string {
+	if len(owners) == 0 {
+		return "-"
+	}
+	return strings.Join(owners, ",")
+}
+
+func formatTTL(ttl time.Duration) string {
+	switch {
+	case ttl == -1:
+		return "永不过期"
+	case ttl <= 0:
+		return "-"
+	default:
+		return ttl.Round(time.Millisecond).String()
+	}
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return "unknown"
+}
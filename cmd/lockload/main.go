@@ -0,0 +1,158 @@
+// lockload 分布式锁压测工具
+//
+// 依次以每种获取方式、每个并发数争抢同一把锁，输出获取/释放的吞吐量和获取延迟的 p50/p99，
+// 可导出 JSON 用于回归对比。
+//
+// 用法：
+//
+//	lockload [连接参数] [-modes setnx,backoff,pubsub] [-goroutines 1,10,100] [-duration 5s] [-json result.json]
+//
+// 连接参数见 goRedisLock/config
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"goRedisLock/config"
+	"goRedisLock/internal/lockload"
+	"goRedisLock/lock"
+)
+
+// Report 导出的 JSON 报告
+type Report struct {
+	Time     time.Time          `json:"time"`
+	Redis    string             `json:"redis"`
+	Duration time.Duration      `json:"duration_ns,omitempty"`
+	Ops      int                `json:"ops,omitempty"`
+	Hold     time.Duration      `json:"hold_ns"`
+	Results  []*lockload.Result `json:"results"`
+}
+
+func main() {
+	modes := flag.String("modes", "setnx,backoff,pubsub", "获取方式，逗号分隔: setnx, backoff, pubsub")
+	goroutines := flag.String("goroutines", "1,10,100", "并发数，逗号分隔")
+	duration := flag.Duration("duration", 5*time.Second, "每组压测的时长")
+	ops := flag.Int("ops", 0, "每组压测的获取/释放次数，指定时忽略 -duration")
+	key := flag.String("key", "lockload", "争抢的锁 key")
+	ttl := flag.Duration("ttl", lock.DefaultTTL, "锁过期时间")
+	hold := flag.Duration("hold", 0, "每次持有锁的时长")
+	jsonPath := flag.String("json", "", "导出 JSON 报告的路径，- 表示输出到标准输出")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal("加载Redis配置失败: ", err)
+	}
+
+	modeList, err := parseModes(*modes)
+	if err != nil {
+		log.Fatal(err)
+	}
+	counts, err := parseInts(*goroutines)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rdb, err := cfg.NewClient()
+	if err != nil {
+		log.Fatal("创建Redis客户端失败: ", err)
+	}
+	defer rdb.Close()
+	ctx := context.Background()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		log.Fatalf("Redis连接失败，请确保Redis服务在 %s 运行: %v", cfg.Addr(), err)
+	}
+	if err := rdb.Del(ctx, *key).Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	report := &Report{Time: time.Now(), Redis: cfg.Addr(), Hold: *hold}
+	if *ops > 0 {
+		report.Ops = *ops
+	} else {
+		report.Duration = *duration
+	}
+	client := lock.NewClient(rdb)
+	for _, mode := range modeList {
+		for _, n := range counts {
+			r, err := lockload.Run(ctx, client, lockload.Config{
+				Mode:       mode,
+				Goroutines: n,
+				Ops:        *ops,
+				Duration:   *duration,
+				Key:        *key,
+				TTL:        *ttl,
+				Hold:       *hold,
+			})
+			if err != nil {
+				log.Fatal(err)
+			}
+			log.Print(r)
+			report.Results = append(report.Results, r)
+		}
+	}
+
+	if *jsonPath != "-" {
+		printTable(report.Results)
+	}
+	if *jsonPath != "" {
+		if err := writeJSON(*jsonPath, report); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func printTable(results []*lockload.Result) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "MODE\tGOROUTINES\tOPS\tERRORS\tOPS/S\tP50\tP99\tMAX\t")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.1f\t%v\t%v\t%v\t\n",
+			r.Mode, r.Goroutines, r.Ops, r.Errors, r.OpsPerSec,
+			r.P50.Round(time.Microsecond), r.P99.Round(time.Microsecond), r.Max.Round(time.Microsecond))
+	}
+	w.Flush()
+}
+
+func writeJSON(path string, report *Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func parseModes(s string) ([]lockload.Mode, error) {
+	var modes []lockload.Mode
+	for _, item := range strings.Split(s, ",") {
+		m, err := lockload.ParseMode(strings.TrimSpace(item))
+		if err != nil {
+			return nil, err
+		}
+		modes = append(modes, m)
+	}
+	return modes, nil
+}
+
+func parseInts(s string) ([]int, error) {
+	var list []int
+	for _, item := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("无效的并发数: %q", item)
+		}
+		list = append(list, n)
+	}
+	return list, nil
+}
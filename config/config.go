@@ -0,0 +1,338 @@
+// Package config 加载 Redis 连接配置，支持 YAML 配置文件、环境变量和命令行参数，
+// 优先级从低到高依次为：默认值、配置文件、环境变量、命令行参数
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"gopkg.in/yaml.v3"
+)
+
+// 连接模式
+const (
+	ModeStandalone = "standalone" // 单机
+	ModeSentinel   = "sentinel"   // 哨兵
+	ModeCluster    = "cluster"    // 集群
+)
+
+// EnvPrefix 默认的环境变量前缀，例如 REDIS_ADDR、REDIS_PASSWORD
+const EnvPrefix = "REDIS_"
+
+// Config Redis 连接配置
+type Config struct {
+	Mode     string   `yaml:"mode"`     // 连接模式，默认 standalone
+	Addrs    []string `yaml:"addrs"`    // 地址列表：单机模式只取第一个，哨兵模式为哨兵地址，集群模式为种子节点
+	Username string   `yaml:"username"` // ACL 用户名
+	Password string   `yaml:"password"` // 密码
+	DB       int      `yaml:"db"`       // 数据库，集群模式下必须为 0
+
+	MasterName       string `yaml:"master_name"`       // 哨兵模式的主节点名称
+	SentinelPassword string `yaml:"sentinel_password"` // 哨兵的密码
+
+	TLS TLSConfig `yaml:"tls"`
+
+	PoolSize     int           `yaml:"pool_size"`      // 连接池大小，0 使用 go-redis 默认值
+	MinIdleConns int           `yaml:"min_idle_conns"` // 最少空闲连接数
+	DialTimeout  time.Duration `yaml:"dial_timeout"`   // 建立连接超时
+	ReadTimeout  time.Duration `yaml:"read_timeout"`   // 读超时
+	WriteTimeout time.Duration `yaml:"write_timeout"`  // 写超时
+	PoolTimeout  time.Duration `yaml:"pool_timeout"`   // 从连接池获取连接的超时
+}
+
+// TLSConfig TLS 配置
+type TLSConfig struct {
+	Enabled            bool   `yaml:"enabled"`
+	CAFile             string `yaml:"ca_file"`              // 校验服务端证书的 CA，为空时使用系统 CA
+	CertFile           string `yaml:"cert_file"`            // 客户端证书（双向认证）
+	KeyFile            string `yaml:"key_file"`             // 客户端私钥
+	ServerName         string `yaml:"server_name"`          // 校验证书时使用的服务端名称
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"` // 跳过证书校验，仅用于测试
+}
+
+// Default 返回默认配置：单机模式连接 localhost:6379
+func Default() *Config {
+	return &Config{
+		Mode:        ModeStandalone,
+		Addrs:       []string{"localhost:6379"},
+		DialTimeout: 5 * time.Second,
+		ReadTimeout: 3 * time.Second,
+	}
+}
+
+// Addr 返回地址列表的字符串形式，用于日志和错误提示
+func (c *Config) Addr() string {
+	return strings.Join(c.Addrs, ",")
+}
+
+// Load 按优先级加载配置：默认值、配置文件、环境变量（REDIS_ 前缀）、命令行参数。
+// 配置文件路径由 -redis-config 参数或 REDIS_CONFIG 环境变量指定。
+// 会在 fs 上注册 Redis 相关参数并解析 args
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	cfg := Default()
+	path := cfg.RegisterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// 记录命令行显式指定的参数，加载配置文件和环境变量后重新应用，保证命令行优先
+	explicit := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = f.Value.String()
+	})
+
+	if *path == "" {
+		*path = os.Getenv(EnvPrefix + "CONFIG")
+	}
+	if *path != "" {
+		if err := cfg.LoadFile(*path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.LoadEnv(EnvPrefix); err != nil {
+		return nil, err
+	}
+	for name, value := range explicit {
+		if err := fs.Set(name, value); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, cfg.Validate()
+}
+
+// LoadFile 从 YAML 文件加载配置，文件中未出现的字段保持原值
+func (c *Config) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: 读取配置文件失败: %w", err)
+	}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("config: 解析配置文件 %s 失败: %w", path, err)
+	}
+	return nil
+}
+
+// LoadEnv 从环境变量加载配置，未设置的环境变量保持原值。
+// 变量名为 prefix 加字段名，例如 prefix 为 REDIS_ 时读取 REDIS_ADDR（逗号分隔多个地址）、
+// REDIS_PASSWORD、REDIS_DB、REDIS_MODE、REDIS_TLS、REDIS_POOL_SIZE、REDIS_DIAL_TIMEOUT 等
+func (c *Config) LoadEnv(prefix string) error {
+	var errs []error
+	str := func(name string, dst *string) {
+		if v, ok := os.LookupEnv(prefix + name); ok {
+			*dst = v
+		}
+	}
+	integer := func(name string, dst *int) {
+		if v, ok := os.LookupEnv(prefix + name); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("config: %s%s 不是整数: %q", prefix, name, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	boolean := func(name string, dst *bool) {
+		if v, ok := os.LookupEnv(prefix + name); ok {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("config: %s%s 不是布尔值: %q", prefix, name, v))
+				return
+			}
+			*dst = b
+		}
+	}
+	duration := func(name string, dst *time.Duration) {
+		if v, ok := os.LookupEnv(prefix + name); ok {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("config: %s%s 不是时长: %q", prefix, name, v))
+				return
+			}
+			*dst = d
+		}
+	}
+
+	str("MODE", &c.Mode)
+	if v, ok := os.LookupEnv(prefix + "ADDR"); ok {
+		c.Addrs = splitList(v)
+	}
+	str("USERNAME", &c.Username)
+	str("PASSWORD", &c.Password)
+	integer("DB", &c.DB)
+	str("MASTER_NAME", &c.MasterName)
+	str("SENTINEL_PASSWORD", &c.SentinelPassword)
+	boolean("TLS", &c.TLS.Enabled)
+	str("TLS_CA_FILE", &c.TLS.CAFile)
+	str("TLS_CERT_FILE", &c.TLS.CertFile)
+	str("TLS_KEY_FILE", &c.TLS.KeyFile)
+	str("TLS_SERVER_NAME", &c.TLS.ServerName)
+	boolean("TLS_INSECURE_SKIP_VERIFY", &c.TLS.InsecureSkipVerify)
+	integer("POOL_SIZE", &c.PoolSize)
+	integer("MIN_IDLE_CONNS", &c.MinIdleConns)
+	duration("DIAL_TIMEOUT", &c.DialTimeout)
+	duration("READ_TIMEOUT", &c.ReadTimeout)
+	duration("WRITE_TIMEOUT", &c.WriteTimeout)
+	duration("POOL_TIMEOUT", &c.PoolTimeout)
+	return errors.Join(errs...)
+}
+
+// RegisterFlags 在 fs 上注册 Redis 相关的命令行参数（均以 redis- 开头），
+// 参数直接写入 c，默认值为 c 的当前值。返回配置文件路径参数
+func (c *Config) RegisterFlags(fs *flag.FlagSet) *string {
+	path := fs.String("redis-config", "", "Redis YAML配置文件路径")
+	fs.StringVar(&c.Mode, "redis-mode", c.Mode, "连接模式: standalone, sentinel, cluster")
+	fs.Var((*listValue)(&c.Addrs), "redis-addr", "Redis地址，多个地址用逗号分隔")
+	fs.StringVar(&c.Username, "redis-username", c.Username, "Redis ACL用户名")
+	fs.StringVar(&c.Password, "redis-password", c.Password, "Redis密码")
+	fs.IntVar(&c.DB, "redis-db", c.DB, "Redis数据库")
+	fs.StringVar(&c.MasterName, "redis-master-name", c.MasterName, "哨兵模式的主节点名称")
+	fs.StringVar(&c.SentinelPassword, "redis-sentinel-password", c.SentinelPassword, "哨兵密码")
+	fs.BoolVar(&c.TLS.Enabled, "redis-tls", c.TLS.Enabled, "使用TLS连接")
+	fs.StringVar(&c.TLS.CAFile, "redis-tls-ca-file", c.TLS.CAFile, "TLS CA证书文件")
+	fs.StringVar(&c.TLS.CertFile, "redis-tls-cert-file", c.TLS.CertFile, "TLS客户端证书文件")
+	fs.StringVar(&c.TLS.KeyFile, "redis-tls-key-file", c.TLS.KeyFile, "TLS客户端私钥文件")
+	fs.StringVar(&c.TLS.ServerName, "redis-tls-server-name", c.TLS.ServerName, "TLS服务端名称")
+	fs.BoolVar(&c.TLS.InsecureSkipVerify, "redis-tls-insecure-skip-verify", c.TLS.InsecureSkipVerify, "跳过TLS证书校验（仅用于测试）")
+	fs.IntVar(&c.PoolSize, "redis-pool-size", c.PoolSize, "连接池大小")
+	fs.IntVar(&c.MinIdleConns, "redis-min-idle-conns", c.MinIdleConns, "最少空闲连接数")
+	fs.DurationVar(&c.DialTimeout, "redis-dial-timeout", c.DialTimeout, "建立连接超时")
+	fs.DurationVar(&c.ReadTimeout, "redis-read-timeout", c.ReadTimeout, "读超时")
+	fs.DurationVar(&c.WriteTimeout, "redis-write-timeout", c.WriteTimeout, "写超时")
+	fs.DurationVar(&c.PoolTimeout, "redis-pool-timeout", c.PoolTimeout, "从连接池获取连接的超时")
+	return path
+}
+
+// Validate 校验配置
+func (c *Config) Validate() error {
+	if len(c.Addrs) == 0 {
+		return errors.New("config: 未配置Redis地址")
+	}
+	switch c.Mode {
+	case ModeStandalone:
+		if len(c.Addrs) > 1 {
+			return fmt.Errorf("config: 单机模式只能配置一个地址，实际: %s", c.Addr())
+		}
+	case ModeSentinel:
+		if c.MasterName == "" {
+			return errors.New("config: 哨兵模式必须配置主节点名称")
+		}
+	case ModeCluster:
+		if c.DB != 0 {
+			return errors.New("config: 集群模式不支持选择数据库")
+		}
+	default:
+		return fmt.Errorf("config: 未知的连接模式: %q", c.Mode)
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		return errors.New("config: TLS客户端证书和私钥必须同时配置")
+	}
+	return nil
+}
+
+// UniversalOptions 转换为 go-redis 的连接配置
+func (c *Config) UniversalOptions() (*redis.UniversalOptions, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	tlsConfig, err := c.TLS.build()
+	if err != nil {
+		return nil, err
+	}
+	opts := &redis.UniversalOptions{
+		Addrs:            c.Addrs,
+		Username:         c.Username,
+		Password:         c.Password,
+		DB:               c.DB,
+		SentinelPassword: c.SentinelPassword,
+		TLSConfig:        tlsConfig,
+		PoolSize:         c.PoolSize,
+		MinIdleConns:     c.MinIdleConns,
+		DialTimeout:      c.DialTimeout,
+		ReadTimeout:      c.ReadTimeout,
+		WriteTimeout:     c.WriteTimeout,
+		PoolTimeout:      c.PoolTimeout,
+	}
+	if c.Mode == ModeSentinel {
+		opts.MasterName = c.MasterName
+	}
+	return opts, nil
+}
+
+// NewClient 按连接模式创建 Redis 客户端：单机为 *redis.Client，
+// 哨兵为自动故障转移的 *redis.Client，集群为 *redis.ClusterClient
+func (c *Config) NewClient() (redis.UniversalClient, error) {
+	opts, err := c.UniversalOptions()
+	if err != nil {
+		return nil, err
+	}
+	switch c.Mode {
+	case ModeSentinel:
+		return redis.NewFailoverClient(opts.Failover()), nil
+	case ModeCluster:
+		return redis.NewClusterClient(opts.Cluster()), nil
+	default:
+		return redis.NewClient(opts.Simple()), nil
+	}
+}
+
+// build 生成 TLS 配置，未启用时返回 nil
+func (t TLSConfig) build() (*tls.Config, error) {
+	if !t.Enabled {
+		return nil, nil
+	}
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("config: 读取CA证书失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("config: CA证书 %s 中没有有效的证书", t.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("config: 加载客户端证书失败: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// listValue 逗号分隔的字符串列表参数
+type listValue []string
+
+func (l *listValue) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listValue) Set(s string) error {
+	*l = splitList(s)
+	return nil
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "redis.yaml")
+	yaml := `
+addrs: ["file:6379"]
+password: file-secret
+db: 3
+pool_size: 20
+read_timeout: 2s
+`
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("REDIS_CONFIG", path)
+	t.Setenv("REDIS_PASSWORD", "env-secret")
+	t.Setenv("REDIS_DB", "5")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err := Load(fs, []string{"-redis-db", "7"})
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+
+	// 命令行 > 环境变量 > 配置文件 > 默认值
+	if cfg.Addr() != "file:6379" {
+		t.Errorf("地址应来自配置文件，实际: %s", cfg.Addr())
+	}
+	if cfg.Password != "env-secret" {
+		t.Errorf("密码应来自环境变量，实际: %s", cfg.Password)
+	}
+	if cfg.DB != 7 {
+		t.Errorf("数据库应来自命令行，实际: %d", cfg.DB)
+	}
+	if cfg.PoolSize != 20 || cfg.ReadTimeout != 2*time.Second {
+		t.Errorf("配置文件中的连接池配置未生效: %d, %v", cfg.PoolSize, cfg.ReadTimeout)
+	}
+	if cfg.DialTimeout != 5*time.Second || cfg.Mode != ModeStandalone {
+		t.Errorf("未配置的字段应保持默认值: %v, %s", cfg.DialTimeout, cfg.Mode)
+	}
+}
+
+func TestLoadEnvInvalid(t *testing.T) {
+	t.Setenv("TEST_DB", "abc")
+	t.Setenv("TEST_DIAL_TIMEOUT", "5")
+	if err := Default().LoadEnv("TEST_"); err == nil {
+		t.Error("非法的环境变量应返回错误")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		ok   bool
+	}{
+		{"单机", Config{Mode: ModeStandalone, Addrs: []string{"a:6379"}}, true},
+		{"单机多地址", Config{Mode: ModeStandalone, Addrs: []string{"a:6379", "b:6379"}}, false},
+		{"哨兵缺少主节点名称", Config{Mode: ModeSentinel, Addrs: []string{"a:26379"}}, false},
+		{"哨兵", Config{Mode: ModeSentinel, Addrs: []string{"a:26379"}, MasterName: "mymaster"}, true},
+		{"集群选择数据库", Config{Mode: ModeCluster, Addrs: []string{"a:7000"}, DB: 1}, false},
+		{"未知模式", Config{Mode: "proxy", Addrs: []string{"a:6379"}}, false},
+		{"没有地址", Config{Mode: ModeStandalone}, false},
+		{"证书缺少私钥", Config{Mode: ModeStandalone, Addrs: []string{"a:6379"}, TLS: TLSConfig{CertFile: "c.pem"}}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%s: 期望通过=%v，实际错误: %v", tt.name, tt.ok, err)
+		}
+	}
+}
+
+func TestNewClientMode(t *testing.T) {
+	tests := []struct {
+		cfg     Config
+		cluster bool
+	}{
+		{Config{Mode: ModeStandalone, Addrs: []string{"a:6379"}}, false},
+		{Config{Mode: ModeSentinel, Addrs: []string{"a:26379"}, MasterName: "mymaster"}, false},
+		{Config{Mode: ModeCluster, Addrs: []string{"a:7000", "b:7000"}}, true},
+	}
+	for _, tt := range tests {
+		client, err := tt.cfg.NewClient()
+		if err != nil {
+			t.Fatalf("%s: 创建客户端失败: %v", tt.cfg.Mode, err)
+		}
+		if _, ok := client.(*redis.ClusterClient); ok != tt.cluster {
+			t.Errorf("%s: 客户端类型不正确: %T", tt.cfg.Mode, client)
+		}
+		client.Close()
+	}
+}
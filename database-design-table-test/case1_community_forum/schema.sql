@@ -85,6 +85,7 @@ CREATE TABLE `posts` (
   `comment_count` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '评论数（冗余字段）',
   `is_top` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否置顶：0-否 1-是',
   `is_deleted` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否删除（软删除）',
+  `fence_token` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '最近一次写入携带的分布式锁 fencing token',
   `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
   `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   `deleted_at` DATETIME DEFAULT NULL,
@@ -216,3 +217,24 @@ CREATE TABLE `section_moderators` (
   CONSTRAINT `fk_sm_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='版主表';
 
+
+-- ==================== 6. 基础设施 ====================
+
+-- 分布式锁表（没有 Redis 的部署使用 goRedisLock/lock.SQLStore）
+-- 释放锁时只清空 owner 和 expires_at，保留该行使 fence_token 单调递增
+CREATE TABLE `distributed_locks` (
+  `lock_key` VARCHAR(191) NOT NULL COMMENT '锁key',
+  `owner` VARCHAR(64) NOT NULL COMMENT '持有者标识，空字符串表示未持有',
+  `expires_at` BIGINT NOT NULL COMMENT '过期时间（毫秒时间戳），0 表示已释放',
+  `fence_token` BIGINT NOT NULL COMMENT 'fencing token，每次获取锁加1',
+  PRIMARY KEY (`lock_key`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='分布式锁表';
+
+-- 用户生命周期状态表（goRedisLock/lifecycle.SQLStateStore）
+-- 按 version 做乐观并发控制：UPDATE ... WHERE user_id = ? AND version = ?，影响行数为 0 即版本冲突
+CREATE TABLE `user_lifecycle_states` (
+  `user_id` VARCHAR(64) NOT NULL COMMENT '用户ID',
+  `state` VARCHAR(32) NOT NULL COMMENT '状态：pending_review/approved/rejected/active/inactive/overdue',
+  `version` BIGINT NOT NULL COMMENT '版本号，每次状态变更加1',
+  PRIMARY KEY (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户生命周期状态表';
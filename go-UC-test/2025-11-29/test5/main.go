@@ -2,11 +2,17 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"os"
 	"sync"
 	"time"
+
+	"goRedisLock/config"
+	"goRedisLock/ratelimit"
 )
 
 // ============================================
@@ -16,6 +22,7 @@ import (
 // 1) 使用 worker pool 控制并发数（例如最多3个并发请求）
 // 2) 失败自动重试（最多重试2次）
 // 3) 所有请求完成后，统计成功/失败数量
+// 4) 所有实例共享 Redis 上的限流配额（每次尝试包括重试都要经过限流）
 // ============================================
 
 type RequestTask struct {
@@ -80,15 +87,13 @@ func fetchWithRetry(ctx context.Context, client *http.Client, url string, maxRet
 	}
 }
 
-// Worker pool 模式的并发请求（带限流）
-func concurrentFetchWithWorkerPool(urls []string, maxWorkers int, maxRetries int, timeoutPerRequest time.Duration) []RequestResult {
+// Worker pool 模式的并发请求（带限流），client 的 Transport 负责限流
+func concurrentFetchWithWorkerPool(client *http.Client, urls []string, maxWorkers int, maxRetries int, timeoutPerRequest time.Duration) []RequestResult {
 	// 任务 channel
 	taskChan := make(chan RequestTask, len(urls))
 	// 结果 channel
 	resultChan := make(chan RequestResult, len(urls))
 
-	client := &http.Client{Timeout: 10 * time.Second}
-
 	// 启动 worker goroutines
 	var wg sync.WaitGroup
 	for i := 0; i < maxWorkers; i++ {
@@ -139,6 +144,26 @@ func min(a, b int) int {
 }
 
 func main() {
+	rate := flag.Int("rate", 2, "所有实例合计每秒最多发出的请求数")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal("加载Redis配置失败: ", err)
+	}
+
+	// 限流配额保存在 Redis 中，同时运行多个实例时共享同一个配额
+	client := &http.Client{Timeout: 10 * time.Second}
+	rdb, err := cfg.NewClient()
+	if err != nil {
+		log.Fatal("创建Redis客户端失败: ", err)
+	}
+	defer rdb.Close()
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		fmt.Printf("Redis连接失败，不限流运行: %v\n", err)
+	} else {
+		limiter := ratelimit.NewClient(rdb).NewLimiter("ratelimit:httpbin.org", &ratelimit.Options{Rate: *rate})
+		client.Transport = ratelimit.Transport(limiter, nil)
+	}
+
 	urls := []string{
 		"https://httpbin.org/delay/1",
 		"https://httpbin.org/delay/1",
@@ -155,10 +180,10 @@ func main() {
 	timeout := 3 * time.Second
 
 	fmt.Printf("=== 练习3：带重试、限流的并发请求 ===\n")
-	fmt.Printf("配置: workers=%d, maxRetries=%d, timeout=%v\n\n", maxWorkers, maxRetries, timeout)
+	fmt.Printf("配置: workers=%d, maxRetries=%d, timeout=%v, rate=%d/s\n\n", maxWorkers, maxRetries, timeout, *rate)
 
 	start := time.Now()
-	results := concurrentFetchWithWorkerPool(urls, maxWorkers, maxRetries, timeout)
+	results := concurrentFetchWithWorkerPool(client, urls, maxWorkers, maxRetries, timeout)
 	totalCost := time.Since(start)
 
 	successCount := 0
@@ -0,0 +1,215 @@
+// Package idempotency 基于 Redis 的幂等键：同一个 key 的任务只执行一次，重复调用直接返回缓存的结果。
+//
+// 第一个调用者通过 SET NX 写入带持有者标识的"执行中"记录并执行任务，成功后把记录替换为结果；
+// 与分布式锁一样，只有持有者才能提交结果或放弃记录。执行中的记录有租约并由执行者定期续期，
+// 执行者崩溃后租约过期，其他调用者接手重新执行。任务失败时删除记录，不缓存错误，之后的调用会重新执行。
+//
+//	store := idempotency.NewStore(rdb, nil)
+//	receipt, err := store.Do(ctx, "charge:"+orderID, 24*time.Hour, func(ctx context.Context) ([]byte, error) {
+//		return charge(ctx, orderID)
+//	})
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"goRedisLock/internal/randid"
+	"goRedisLock/internal/renew"
+	"goRedisLock/lock"
+)
+
+// DefaultLease 未指定时执行中记录的租约
+const DefaultLease = 30 * time.Second
+
+const (
+	pendingPrefix = "pending:" // 执行中："pending:<持有者标识>"
+	donePrefix    = "done:"    // 已完成："done:<结果>"
+)
+
+var (
+	// ErrInProgress 同一个 key 的任务正在由其他调用者执行，且按重试策略等待后仍未完成
+	ErrInProgress = errors.New("idempotency: 任务执行中")
+	// ErrLeaseLost 执行期间租约丢失（续期失败或已被其他调用者接手），结果没有被保存，任务可能被执行了不止一次
+	ErrLeaseLost = errors.New("idempotency: 租约已丢失")
+)
+
+// RedisClient 幂等键依赖的 Redis 命令，redis.UniversalClient 都满足该接口
+type RedisClient interface {
+	redis.Scripter
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd
+	Get(ctx context.Context, key string) *redis.StringCmd
+}
+
+var _ RedisClient = redis.UniversalClient(nil)
+
+// Options 幂等键配置
+type Options struct {
+	// Lease 执行中记录的租约，执行者崩溃后最多经过 Lease 由其他调用者接手，默认 DefaultLease
+	Lease time.Duration
+	// RenewInterval 执行期间续期租约的间隔，默认 Lease/3
+	RenewInterval time.Duration
+	// Retry 任务执行中时重复调用者等待结果的重试策略，放弃时返回 ErrInProgress，默认 lock.DefaultRetry
+	Retry lock.RetryStrategy
+}
+
+// withDefaults 返回补齐租约、续期间隔和重试策略的幂等键配置副本
+func (o *Options) withDefaults() *Options {
+	var opts Options
+	if o != nil {
+		opts = *o
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = DefaultLease
+	}
+	if opts.RenewInterval <= 0 {
+		opts.RenewInterval = opts.Lease / 3
+	}
+	if opts.Retry == nil {
+		opts.Retry = lock.DefaultRetry
+	}
+	return &opts
+}
+
+// Store 保存任务执行状态和结果
+type Store struct {
+	rdb  RedisClient
+	opts Options
+}
+
+// NewStore 基于 Redis 连接创建幂等键存储
+func NewStore(rdb RedisClient, opts *Options) *Store {
+	return &Store{rdb: rdb, opts: *opts.withDefaults()}
+}
+
+// Do 保证 key 上的 fn 只成功执行一次：
+//   - key 上没有记录时执行 fn，成功后把结果保存 ttl 时长并返回；fn 失败时删除记录并返回其错误
+//   - key 上已有结果时不执行 fn，直接返回保存的结果
+//   - 其他调用者正在执行时按重试策略等待其结果，执行者崩溃、租约过期后由当前调用者接手执行
+//
+// ttl 必须大于 0。fn 的 ctx 在租约丢失时被取消（context.Cause 为 ErrLeaseLost）
+func (s *Store) Do(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	if ttl <= 0 {
+		return nil, errors.New("idempotency: ttl 必须大于 0")
+	}
+
+	pending := pendingPrefix + randid.New()
+	for attempt := 1; ; attempt++ {
+		ok, err := s.rdb.SetNX(ctx, key, pending, s.opts.Lease).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return s.run(ctx, key, pending, ttl, fn)
+		}
+
+		val, err := s.rdb.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			// 记录刚好过期或被放弃，立即重新抢占
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(val, donePrefix) {
+			return []byte(val[len(donePrefix):]), nil
+		}
+
+		backoff := s.opts.Retry.Backoff(attempt)
+		if backoff <= 0 {
+			return nil, ErrInProgress
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w: %w", ErrInProgress, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// run 持有执行中记录执行 fn，成功时提交结果，失败或 panic 时放弃记录
+func (s *Store) run(ctx context.Context, key, pending string, ttl time.Duration, fn func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	inner, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	// 执行期间定期续期租约，记录已不属于当前执行者，或连续续期失败超过租约时以 ErrLeaseLost 取消 ctx
+	keepAlive := renew.New(s.opts.Lease, s.opts.RenewInterval, func(ctx context.Context, lease time.Duration) error {
+		res, err := renewScript.Run(ctx, s.rdb, []string{key}, pending, lease.Milliseconds()).Int()
+		if err == nil && res != 1 {
+			return ErrLeaseLost
+		}
+		return err
+	}, ErrLeaseLost)
+	keepAlive.Start(inner)
+
+	lost := keepAlive.Lost()
+	go func() {
+		select {
+		case <-lost:
+			cancel(ErrLeaseLost)
+		case <-inner.Done():
+		}
+	}()
+
+	committed := false
+	defer func() {
+		keepAlive.Stop()
+		if !committed {
+			// 调用方的 ctx 已取消时仍要放弃记录，让重试的调用者可以立即接手
+			abandonScript.Run(context.WithoutCancel(ctx), s.rdb, []string{key}, pending)
+		}
+	}()
+
+	result, err := fn(inner)
+	keepAlive.Stop()
+	if err != nil {
+		select {
+		case <-lost:
+			if !errors.Is(err, ErrLeaseLost) {
+				err = errors.Join(err, ErrLeaseLost)
+			}
+		default:
+		}
+		return nil, err
+	}
+
+	// fn 已经成功执行，调用方的 ctx 随后取消也要提交结果，否则记录被放弃，任务会被再次执行
+	commitCtx, cancelCommit := context.WithTimeout(context.WithoutCancel(ctx), s.opts.Lease)
+	defer cancelCommit()
+	res, err := completeScript.Run(commitCtx, s.rdb, []string{key}, pending, donePrefix+string(result), ttl.Milliseconds()).Int()
+	if err != nil {
+		return result, err
+	}
+	if res != 1 {
+		return result, ErrLeaseLost
+	}
+	committed = true
+	return result, nil
+}
+
+// DoJSON 与 Store.Do 相同，结果以 JSON 编码保存
+func DoJSON[T any](ctx context.Context, s *Store, key string, ttl time.Duration, fn func(ctx context.Context) (T, error)) (T, error) {
+	var v T
+	data, err := s.Do(ctx, key, ttl, func(ctx context.Context) ([]byte, error) {
+		v, err := fn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(v)
+	})
+	if err != nil {
+		return v, err
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, fmt.Errorf("idempotency: 解析保存的结果失败: %w", err)
+	}
+	return v, nil
+}
@@ -0,0 +1,177 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"goRedisLock/internal/redistest"
+	"goRedisLock/lock"
+)
+
+// testKey 幂等键测试使用的 key，每个测试结束时删除
+const testKey = "test_idempotency"
+
+func TestDoRunsOnce(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(redistest.New(t, testKey).Client, &Options{Retry: lock.FixedBackoff(10 * time.Millisecond)})
+
+	var runs int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := store.Do(ctx, testKey, time.Minute, func(ctx context.Context) ([]byte, error) {
+				atomic.AddInt32(&runs, 1)
+				time.Sleep(50 * time.Millisecond)
+				return []byte("ok"), nil
+			})
+			if err != nil || string(res) != "ok" {
+				t.Errorf("期望得到执行结果 ok，实际: %q, %v", res, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if runs != 1 {
+		t.Errorf("任务应只执行1次，实际执行%d次", runs)
+	}
+}
+
+func TestDoCachesResult(t *testing.T) {
+	ctx := context.Background()
+	server := redistest.New(t, testKey)
+	store := NewStore(server.Client, nil)
+
+	var runs int32
+	fn := func(ctx context.Context) ([]byte, error) {
+		return []byte{byte(atomic.AddInt32(&runs, 1))}, nil
+	}
+	first, err := store.Do(ctx, testKey, 2*time.Second, fn)
+	if err != nil {
+		t.Fatalf("执行失败: %v", err)
+	}
+	second, err := store.Do(ctx, testKey, 2*time.Second, fn)
+	if err != nil || string(second) != string(first) || runs != 1 {
+		t.Fatalf("重复调用应返回缓存的结果: %v -> %v, %v, 执行%d次", first, second, err, runs)
+	}
+
+	// 结果过期后重新执行（连接真实 Redis 时需要真实等待，ttl 取较短的值）
+	server.FastForward(2 * 2 * time.Second)
+	if _, err := store.Do(ctx, testKey, 2*time.Second, fn); err != nil || runs != 2 {
+		t.Errorf("结果过期后应重新执行: %v, 执行%d次", err, runs)
+	}
+}
+
+// fn 成功后调用方的 ctx 被取消，结果仍然提交，不会被再次执行
+func TestDoCommitsAfterCancel(t *testing.T) {
+	server := redistest.New(t, testKey)
+	store := NewStore(server.Client, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	res, err := store.Do(ctx, testKey, time.Minute, func(context.Context) ([]byte, error) {
+		cancel()
+		return []byte("charged"), nil
+	})
+	if err != nil || string(res) != "charged" {
+		t.Fatalf("ctx 取消后仍应提交结果: %q, %v", res, err)
+	}
+
+	res, err = store.Do(context.Background(), testKey, time.Minute, func(context.Context) ([]byte, error) {
+		t.Error("结果已提交，不应再次执行")
+		return nil, nil
+	})
+	if err != nil || string(res) != "charged" {
+		t.Errorf("应返回已提交的结果，实际: %q, %v", res, err)
+	}
+}
+
+func TestDoErrorNotCached(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(redistest.New(t, testKey).Client, nil)
+
+	errFetch := errors.New("请求失败")
+	_, err := store.Do(ctx, testKey, time.Minute, func(ctx context.Context) ([]byte, error) {
+		return nil, errFetch
+	})
+	if !errors.Is(err, errFetch) {
+		t.Fatalf("期望返回任务的错误，实际: %v", err)
+	}
+
+	// 失败后记录被删除，重试时重新执行
+	res, err := store.Do(ctx, testKey, time.Minute, func(ctx context.Context) ([]byte, error) {
+		return []byte("ok"), nil
+	})
+	if err != nil || string(res) != "ok" {
+		t.Errorf("失败后重试应重新执行: %q, %v", res, err)
+	}
+}
+
+// 执行者崩溃后租约过期，其他调用者接手执行
+func TestDoTakesOverCrashedEntry(t *testing.T) {
+	ctx := context.Background()
+	server := redistest.New(t, testKey)
+	store := NewStore(server.Client, &Options{Lease: 10 * time.Second, Retry: lock.NoRetry()})
+
+	// 模拟执行者写入执行中记录后崩溃
+	server.Client.Set(ctx, testKey, pendingPrefix+"crashed", 10*time.Second)
+
+	fn := func(ctx context.Context) ([]byte, error) {
+		return []byte("ok"), nil
+	}
+	if _, err := store.Do(ctx, testKey, time.Minute, fn); !errors.Is(err, ErrInProgress) {
+		t.Fatalf("期望 ErrInProgress，实际: %v", err)
+	}
+
+	server.FastForward(11 * time.Second)
+	if res, err := store.Do(ctx, testKey, time.Minute, fn); err != nil || string(res) != "ok" {
+		t.Errorf("租约过期后应接手执行: %q, %v", res, err)
+	}
+}
+
+// 执行期间记录被其他调用者接手时取消任务，结果不会覆盖对方的记录
+func TestDoLeaseLost(t *testing.T) {
+	ctx := context.Background()
+	server := redistest.New(t, testKey)
+	store := NewStore(server.Client, &Options{Lease: time.Second, RenewInterval: 20 * time.Millisecond})
+
+	_, err := store.Do(ctx, testKey, time.Minute, func(ctx context.Context) ([]byte, error) {
+		server.Client.Set(ctx, testKey, pendingPrefix+"other", time.Second)
+		<-ctx.Done()
+		return nil, context.Cause(ctx)
+	})
+	if !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("期望 ErrLeaseLost，实际: %v", err)
+	}
+	if val := server.Client.Get(ctx, testKey).Val(); val != pendingPrefix+"other" {
+		t.Errorf("不应删除其他调用者的记录，实际: %q", val)
+	}
+}
+
+func TestDoJSON(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(redistest.New(t, testKey).Client, nil)
+
+	type receipt struct {
+		OrderID string
+		Amount  int
+	}
+	var runs int32
+	fn := func(ctx context.Context) (receipt, error) {
+		atomic.AddInt32(&runs, 1)
+		return receipt{OrderID: "order_1", Amount: 100}, nil
+	}
+	for i := 0; i < 2; i++ {
+		got, err := DoJSON(ctx, store, testKey, time.Minute, fn)
+		if err != nil || got != (receipt{OrderID: "order_1", Amount: 100}) {
+			t.Fatalf("第%d次调用结果不正确: %+v, %v", i+1, got, err)
+		}
+	}
+	if runs != 1 {
+		t.Errorf("任务应只执行1次，实际执行%d次", runs)
+	}
+}
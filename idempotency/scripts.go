@@ -0,0 +1,28 @@
+package idempotency
+
+import "github.com/go-redis/redis/v8"
+
+// 提交结果：只有执行中记录的持有者才能把记录替换为结果，ARGV: 执行中记录、结果记录、结果保存毫秒数
+var completeScript = redis.NewScript(`
+	if redis.call("get", KEYS[1]) == ARGV[1] then
+		redis.call("set", KEYS[1], ARGV[2], "px", ARGV[3])
+		return 1
+	end
+	return 0
+`)
+
+// 放弃执行：只有持有者才能删除执行中记录
+var abandonScript = redis.NewScript(`
+	if redis.call("get", KEYS[1]) == ARGV[1] then
+		return redis.call("del", KEYS[1])
+	end
+	return 0
+`)
+
+// 续期：只有持有者才能延长执行中记录的租约
+var renewScript = redis.NewScript(`
+	if redis.call("get", KEYS[1]) == ARGV[1] then
+		return redis.call("pexpire", KEYS[1], ARGV[2])
+	end
+	return 0
+`)
@@ -0,0 +1,187 @@
+// Package lockload 对分布式锁施加负载，统计获取/释放的吞吐量和获取延迟，
+// 供 lock 包的基准测试和 cmd/lockload 压测工具共用
+package lockload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"goRedisLock/lock"
+)
+
+// Mode 获取锁的方式
+type Mode string
+
+const (
+	// ModeSetNX 不等待，获取失败后立即再次 SETNX（忙等）
+	ModeSetNX Mode = "setnx"
+	// ModeBackoff 阻塞获取，按 lock.DefaultRetry 指数退避重试
+	ModeBackoff Mode = "backoff"
+	// ModePubSub 阻塞获取，订阅锁释放通知，收到通知后立即重试
+	ModePubSub Mode = "pubsub"
+)
+
+// Modes 全部获取方式
+var Modes = []Mode{ModeSetNX, ModeBackoff, ModePubSub}
+
+// ParseMode 解析获取方式
+func ParseMode(s string) (Mode, error) {
+	for _, m := range Modes {
+		if string(m) == s {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("lockload: 未知的获取方式: %q", s)
+}
+
+// Config 一次压测的参数
+type Config struct {
+	Mode       Mode
+	Goroutines int           // 并发争抢同一把锁的 goroutine 数
+	Ops        int           // 总的获取/释放次数，为 0 时按 Duration 运行
+	Duration   time.Duration // Ops 为 0 时的运行时长
+	Key        string
+	TTL        time.Duration // 锁过期时间，默认 lock.DefaultTTL
+	Hold       time.Duration // 每次持有锁的时长
+}
+
+// Result 一次压测的结果，延迟为获取锁的耗时（含排队等待）
+type Result struct {
+	Mode       Mode          `json:"mode"`
+	Goroutines int           `json:"goroutines"`
+	Ops        int           `json:"ops"`
+	Errors     int           `json:"errors"`
+	Elapsed    time.Duration `json:"elapsed_ns"`
+	OpsPerSec  float64       `json:"ops_per_sec"`
+	P50        time.Duration `json:"p50_ns"`
+	P99        time.Duration `json:"p99_ns"`
+	Max        time.Duration `json:"max_ns"`
+}
+
+func (r *Result) String() string {
+	return fmt.Sprintf("%-8s goroutines=%-4d ops=%-7d ops/s=%-10.1f p50=%-10v p99=%-10v max=%v",
+		r.Mode, r.Goroutines, r.Ops, r.OpsPerSec, r.P50, r.P99, r.Max)
+}
+
+// Run 按 cfg 运行一次压测
+func Run(ctx context.Context, client *lock.Client, cfg Config) (*Result, error) {
+	if cfg.Goroutines <= 0 {
+		return nil, errors.New("lockload: goroutine 数必须大于 0")
+	}
+	if cfg.Ops <= 0 && cfg.Duration <= 0 {
+		return nil, errors.New("lockload: 必须指定次数或时长")
+	}
+	if cfg.Duration > 0 && cfg.Ops <= 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+
+	var (
+		issued    int64 // 已领取的操作数，Ops 模式下用于分配任务
+		errCount  int64
+		mu        sync.Mutex
+		latencies []time.Duration
+		wg        sync.WaitGroup
+	)
+	start := time.Now()
+	for i := 0; i < cfg.Goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var local []time.Duration
+			for ctx.Err() == nil {
+				if cfg.Ops > 0 && atomic.AddInt64(&issued, 1) > int64(cfg.Ops) {
+					break
+				}
+				d, err := cycle(ctx, client, cfg)
+				if err != nil {
+					// 按时长运行时，结束时正在等待的获取会因 ctx 结束而失败，不计入错误
+					if ctx.Err() == nil {
+						atomic.AddInt64(&errCount, 1)
+					}
+					continue
+				}
+				local = append(local, d)
+			}
+			mu.Lock()
+			latencies = append(latencies, local...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	r := &Result{
+		Mode:       cfg.Mode,
+		Goroutines: cfg.Goroutines,
+		Ops:        len(latencies),
+		Errors:     int(errCount),
+		Elapsed:    time.Since(start),
+	}
+	r.OpsPerSec = float64(r.Ops) / r.Elapsed.Seconds()
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	r.P50 = percentile(latencies, 0.50)
+	r.P99 = percentile(latencies, 0.99)
+	r.Max = percentile(latencies, 1)
+	return r, nil
+}
+
+// cycle 获取锁、持有 Hold 后释放，返回获取锁的耗时
+func cycle(ctx context.Context, client *lock.Client, cfg Config) (time.Duration, error) {
+	l := client.NewLock(cfg.Key, &lock.Options{
+		TTL:           cfg.TTL,
+		WakeOnRelease: cfg.Mode == ModePubSub,
+	})
+
+	start := time.Now()
+	var err error
+	if cfg.Mode == ModeSetNX {
+		err = spin(ctx, l)
+	} else {
+		err = l.Lock(ctx)
+	}
+	if err != nil {
+		return 0, err
+	}
+	wait := time.Since(start)
+
+	if cfg.Hold > 0 {
+		time.Sleep(cfg.Hold)
+	}
+	// 按时长运行结束时也要释放已持有的锁
+	return wait, l.Unlock(context.WithoutCancel(ctx))
+}
+
+// spin 反复 TryLock 直到获取成功
+func spin(ctx context.Context, l *lock.RedisLock) error {
+	for {
+		ok, err := l.TryLock(ctx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("%w: %w", lock.ErrNotObtained, err)
+		}
+	}
+}
+
+// percentile 返回已排序的 sorted 中第 p 分位的值
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(float64(len(sorted))*p)) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
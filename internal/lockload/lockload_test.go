@@ -0,0 +1,57 @@
+package lockload
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"goRedisLock/internal/redistest"
+	"goRedisLock/lock"
+)
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	server := redistest.New(t)
+	client := lock.NewClient(server.Client)
+
+	for _, mode := range Modes {
+		r, err := Run(ctx, client, Config{Mode: mode, Goroutines: 4, Ops: 40, Key: "test_lockload"})
+		if err != nil {
+			t.Fatalf("%s: 压测失败: %v", mode, err)
+		}
+		if r.Ops != 40 || r.Errors != 0 || r.OpsPerSec <= 0 {
+			t.Errorf("%s: 结果不正确: %+v", mode, r)
+		}
+		if r.P50 > r.P99 || r.P99 > r.Max {
+			t.Errorf("%s: 分位数应单调: %+v", mode, r)
+		}
+	}
+	if server.Client.Exists(ctx, "test_lockload").Val() != 0 {
+		t.Error("压测结束后应释放锁")
+	}
+
+	// 按时长运行
+	r, err := Run(ctx, client, Config{Mode: ModeBackoff, Goroutines: 2, Duration: 100 * time.Millisecond, Key: "test_lockload"})
+	if err != nil || r.Ops == 0 || r.Errors != 0 {
+		t.Errorf("按时长运行结果不正确: %+v, %v", r, err)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i))
+	}
+	if p := percentile(sorted, 0.5); p != 50 {
+		t.Errorf("p50 应为 50，实际: %v", p)
+	}
+	if p := percentile(sorted, 0.99); p != 99 {
+		t.Errorf("p99 应为 99，实际: %v", p)
+	}
+	if p := percentile(sorted, 1); p != 100 {
+		t.Errorf("max 应为 100，实际: %v", p)
+	}
+	if p := percentile(nil, 0.5); p != 0 {
+		t.Errorf("没有样本时应为 0，实际: %v", p)
+	}
+}
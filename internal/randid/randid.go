@@ -0,0 +1,16 @@
+// Package randid 生成随机标识：锁持有者、选举候选者、幂等键执行者和限流请求共用
+package randid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// New 返回 128 位随机数的十六进制表示；系统随机源不可用时 panic
+func New() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
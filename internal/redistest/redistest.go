@@ -0,0 +1,104 @@
+// Package redistest 为测试提供 Redis 连接：默认启动进程内的 miniredis，
+// 设置环境变量 TEST_REDIS_ADDR 时连接真实的 Redis 服务，
+// 其余连接参数同样从 TEST_REDIS_ 前缀的环境变量读取（如 TEST_REDIS_PASSWORD、TEST_REDIS_TLS）
+package redistest
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+
+	"goRedisLock/config"
+)
+
+// AddrEnv 指定真实 Redis 地址的环境变量，例如 TEST_REDIS_ADDR=localhost:6379
+const AddrEnv = EnvPrefix + "ADDR"
+
+// EnvPrefix 真实 Redis 连接配置的环境变量前缀
+const EnvPrefix = "TEST_REDIS_"
+
+// ClusterAddrEnv 指定真实 Redis Cluster 种子节点的环境变量
+const ClusterAddrEnv = EnvPrefix + "CLUSTER_ADDR"
+
+// Server 测试用的 Redis 服务
+type Server struct {
+	Client  *redis.Client        // New 创建的单机连接
+	Cluster *redis.ClusterClient // NewCluster 创建的集群连接
+	mini    *miniredis.Miniredis // 连接真实 Redis 时为 nil
+}
+
+// New 创建测试用的 Redis 连接，测试结束时删除 keys 并关闭连接。
+// 连接真实 Redis 时 keys 应包含测试用到的全部 key，避免遗留数据影响之后的测试
+func New(t testing.TB, keys ...string) *Server {
+	t.Helper()
+
+	cfg := config.Default()
+	s := &Server{}
+	if os.Getenv(AddrEnv) == "" {
+		s.mini = miniredis.RunT(t)
+		cfg.Addrs = []string{s.mini.Addr()}
+	} else if err := cfg.LoadEnv(EnvPrefix); err != nil {
+		t.Fatalf("加载Redis配置失败: %v", err)
+	}
+
+	opts, err := cfg.UniversalOptions()
+	if err != nil {
+		t.Fatalf("Redis配置无效: %v", err)
+	}
+	s.Client = redis.NewClient(opts.Simple())
+	if err := s.Client.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("Redis连接失败: %v", err)
+	}
+	t.Cleanup(func() { s.Client.Close() })
+	if len(keys) > 0 {
+		t.Cleanup(func() { s.Client.Del(context.Background(), keys...) })
+	}
+	return s
+}
+
+// NewCluster 创建测试用的 Redis Cluster 连接，测试结束时关闭。
+// 默认以单个 miniredis 作为集群替身（持有全部 16384 个 slot，不校验 CROSSSLOT），
+// 设置环境变量 TEST_REDIS_CLUSTER_ADDR（逗号分隔的种子节点）时连接真实集群
+func NewCluster(t testing.TB) *Server {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.Mode = config.ModeCluster
+	s := &Server{}
+	if addrs := os.Getenv(ClusterAddrEnv); addrs != "" {
+		cfg.Addrs = strings.Split(addrs, ",")
+	} else {
+		s.mini = miniredis.RunT(t)
+		cfg.Addrs = []string{s.mini.Addr()}
+	}
+
+	opts, err := cfg.UniversalOptions()
+	if err != nil {
+		t.Fatalf("Redis配置无效: %v", err)
+	}
+	s.Cluster = redis.NewClusterClient(opts.Cluster())
+	if err := s.Cluster.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("Redis集群连接失败: %v", err)
+	}
+	t.Cleanup(func() { s.Cluster.Close() })
+	return s
+}
+
+// FastForward 让 key 的过期时间流逝 d：miniredis 直接快进，真实 Redis 则等待 d
+func (s *Server) FastForward(d time.Duration) {
+	if s.mini != nil {
+		s.mini.FastForward(d)
+		return
+	}
+	time.Sleep(d)
+}
+
+// Miniredis 返回进程内的 miniredis，连接真实 Redis 时返回 nil
+func (s *Server) Miniredis() *miniredis.Miniredis {
+	return s.mini
+}
@@ -0,0 +1,109 @@
+// Package renew 租约看门狗：持有期间定期续期，续期失败时发出租约丢失信号。
+// lock 包的各类锁和 idempotency 包的执行中记录共用同一个续期循环
+package renew
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Watchdog 看门狗：Start 之后每隔 interval 把租约延长到 ttl，直到 Stop 或 ctx 取消
+type Watchdog struct {
+	ttl      time.Duration
+	interval time.Duration
+	refresh  func(ctx context.Context, ttl time.Duration) error
+	notHeld  error
+
+	// OnRenewError 每次续期失败时回调，可为空；需要在 Start 之前设置
+	OnRenewError func(ctx context.Context, err error)
+
+	mu     sync.Mutex
+	cancel context.CancelFunc // 停止看门狗
+	done   chan struct{}      // 看门狗退出后关闭
+	lost   chan struct{}      // 续期失败、租约已丢失时关闭
+}
+
+// New 创建看门狗。refresh 返回的错误匹配 notHeld（errors.Is）时表示租约已不属于当前持有者，
+// 立即发出丢失信号；其他错误视为临时错误，在租约过期前继续重试
+func New(ttl, interval time.Duration, refresh func(ctx context.Context, ttl time.Duration) error, notHeld error) *Watchdog {
+	return &Watchdog{
+		ttl:      ttl,
+		interval: interval,
+		refresh:  refresh,
+		notHeld:  notHeld,
+		lost:     make(chan struct{}),
+	}
+}
+
+// Lost 返回租约丢失信号，每次 Start 都会生成新的信号
+func (w *Watchdog) Lost() <-chan struct{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.lost
+}
+
+// Start 启动看门狗，ctx 取消时停止续期；已在运行时先停止之前的续期
+func (w *Watchdog) Start(ctx context.Context) {
+	w.Stop()
+
+	ctx, cancel := context.WithCancel(ctx)
+	lost := make(chan struct{})
+	done := make(chan struct{})
+
+	w.mu.Lock()
+	w.cancel = cancel
+	w.done = done
+	w.lost = lost
+	w.mu.Unlock()
+
+	go w.run(ctx, lost, done)
+}
+
+// Stop 停止看门狗并等待其退出，可以重复调用
+func (w *Watchdog) Stop() {
+	w.mu.Lock()
+	cancel, done := w.cancel, w.done
+	w.cancel, w.done = nil, nil
+	w.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// run 定期续期，租约不再属于当前持有者，或连续续期失败超过 TTL 时关闭 lost
+func (w *Watchdog) run(ctx context.Context, lost, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	lastRenewed := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := w.refresh(ctx, w.ttl)
+		if err == nil {
+			lastRenewed = time.Now()
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if w.OnRenewError != nil {
+			w.OnRenewError(ctx, err)
+		}
+		// 网络抖动等临时错误在租约过期前继续重试
+		if errors.Is(err, w.notHeld) || time.Since(lastRenewed) >= w.ttl {
+			close(lost)
+			return
+		}
+	}
+}
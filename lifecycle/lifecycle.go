@@ -0,0 +1,171 @@
+// Package lifecycle 用户生命周期状态机：审核（待审核 -> 通过/拒绝）和消费活跃度（活跃/不活跃/逾期）。
+//
+// 状态和事件都是带类型的常量，状态转换表 DefaultTable 可以独立于 User 查看、校验和测试：
+//
+//	next, ok := lifecycle.DefaultTable.Next(lifecycle.StateInactive, lifecycle.EventConsume) // active, true
+//	err := lifecycle.DefaultTable.Validate()
+package lifecycle
+
+import (
+	"errors"
+	"fmt"
+)
+
+// State 用户状态
+type State string
+
+const (
+	StatePendingReview State = "pending_review" // 待审核（初始状态）
+	StateApproved      State = "approved"       // 审核通过
+	StateRejected      State = "rejected"       // 审核拒绝
+	StateActive        State = "active"         // 活跃（审核通过后初始状态）
+	StateInactive      State = "inactive"       // 不活跃（30天无消耗）
+	StateOverdue       State = "overdue"        // 逾期（90天无消耗）
+)
+
+// Event 触发状态转换的事件
+type Event string
+
+const (
+	EventApprove      Event = "approve"        // 审批通过操作
+	EventReject       Event = "reject"         // 审批拒绝操作
+	EventConsume      Event = "consume"        // 用户发生消费
+	EventNoConsume30d Event = "no_consume_30d" // 定时任务检测到30天无消耗
+	EventNoConsume90d Event = "no_consume_90d" // 定时任务检测到90天无消耗
+)
+
+// ErrInvalidTransition 当前状态下不能执行该事件
+var ErrInvalidTransition = errors.New("lifecycle: 非法的状态转换")
+
+// Transition 状态转换：处于 Src 中任一状态时执行 Event 进入 Dst
+type Transition struct {
+	Event Event
+	Src   []State
+	Dst   State
+}
+
+// Table 状态转换表
+type Table struct {
+	Initial     State   // 初始状态
+	States      []State // 所有状态
+	Transitions []Transition
+}
+
+// DefaultTable 用户生命周期的状态转换表
+var DefaultTable = Table{
+	Initial: StatePendingReview,
+	States: []State{
+		StatePendingReview, StateApproved, StateRejected,
+		StateActive, StateInactive, StateOverdue,
+	},
+	Transitions: []Transition{
+		// 审核相关事件
+		{Event: EventApprove, Src: []State{StatePendingReview}, Dst: StateApproved},
+		{Event: EventReject, Src: []State{StatePendingReview}, Dst: StateRejected},
+
+		// 消费状态流转事件
+		// 审核通过后，用户初始为"活跃"状态，可以理解为"已激活"
+		{Event: EventNoConsume30d, Src: []State{StateApproved, StateActive}, Dst: StateInactive},
+		{Event: EventNoConsume90d, Src: []State{StateInactive}, Dst: StateOverdue},
+		// 用户一旦消费，无论是从"Inactive"还是"Overdue"，都回归"Active"状态
+		{Event: EventConsume, Src: []State{StateInactive, StateOverdue}, Dst: StateActive},
+	},
+}
+
+// Next 返回 src 状态下执行 event 后的状态，不允许该转换时 ok 为 false
+func (t Table) Next(src State, event Event) (dst State, ok bool) {
+	for _, tr := range t.Transitions {
+		if tr.Event != event {
+			continue
+		}
+		for _, s := range tr.Src {
+			if s == src {
+				return tr.Dst, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Events 返回 src 状态下可以执行的事件，按转换表中的顺序
+func (t Table) Events(src State) []Event {
+	var events []Event
+	for _, tr := range t.Transitions {
+		for _, s := range tr.Src {
+			if s == src {
+				events = append(events, tr.Event)
+				break
+			}
+		}
+	}
+	return events
+}
+
+// Has 判断 state 是否是转换表中的状态
+func (t Table) Has(state State) bool {
+	for _, s := range t.States {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate 校验转换表：状态不重复，转换只引用已声明的状态，同一状态下同一事件只有一个目标，
+// 所有状态都能从初始状态到达
+func (t Table) Validate() error {
+	var errs []error
+	seen := make(map[State]bool, len(t.States))
+	for _, s := range t.States {
+		if seen[s] {
+			errs = append(errs, fmt.Errorf("状态 %q 重复声明", s))
+		}
+		seen[s] = true
+	}
+	if !seen[t.Initial] {
+		errs = append(errs, fmt.Errorf("初始状态 %q 未声明", t.Initial))
+	}
+
+	type edge struct {
+		src   State
+		event Event
+	}
+	dsts := make(map[edge]State)
+	for _, tr := range t.Transitions {
+		if len(tr.Src) == 0 {
+			errs = append(errs, fmt.Errorf("事件 %q 没有源状态", tr.Event))
+		}
+		if !seen[tr.Dst] {
+			errs = append(errs, fmt.Errorf("事件 %q 的目标状态 %q 未声明", tr.Event, tr.Dst))
+		}
+		for _, s := range tr.Src {
+			if !seen[s] {
+				errs = append(errs, fmt.Errorf("事件 %q 的源状态 %q 未声明", tr.Event, s))
+			}
+			e := edge{s, tr.Event}
+			if dst, ok := dsts[e]; ok && dst != tr.Dst {
+				errs = append(errs, fmt.Errorf("状态 %q 下事件 %q 有多个目标: %q, %q", s, tr.Event, dst, tr.Dst))
+			}
+			dsts[e] = tr.Dst
+		}
+	}
+
+	reachable := map[State]bool{t.Initial: true}
+	queue := []State{t.Initial}
+	for len(queue) > 0 {
+		src := queue[0]
+		queue = queue[1:]
+		for _, event := range t.Events(src) {
+			if dst, _ := t.Next(src, event); !reachable[dst] {
+				reachable[dst] = true
+				queue = append(queue, dst)
+			}
+		}
+	}
+	for _, s := range t.States {
+		if !reachable[s] {
+			errs = append(errs, fmt.Errorf("状态 %q 无法从初始状态到达", s))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,126 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefaultTableValid(t *testing.T) {
+	if err := DefaultTable.Validate(); err != nil {
+		t.Fatalf("默认转换表校验失败: %v", err)
+	}
+}
+
+func TestTableNext(t *testing.T) {
+	cases := []struct {
+		src   State
+		event Event
+		dst   State
+		ok    bool
+	}{
+		{StatePendingReview, EventApprove, StateApproved, true},
+		{StatePendingReview, EventReject, StateRejected, true},
+		{StateApproved, EventNoConsume30d, StateInactive, true},
+		{StateActive, EventNoConsume30d, StateInactive, true},
+		{StateInactive, EventNoConsume90d, StateOverdue, true},
+		{StateInactive, EventConsume, StateActive, true},
+		{StateOverdue, EventConsume, StateActive, true},
+		{StateApproved, EventApprove, "", false},
+		{StateRejected, EventConsume, "", false},
+		{StateActive, EventNoConsume90d, "", false},
+	}
+	for _, c := range cases {
+		dst, ok := DefaultTable.Next(c.src, c.event)
+		if dst != c.dst || ok != c.ok {
+			t.Errorf("%s + %s: 期望 %q, %v，实际 %q, %v", c.src, c.event, c.dst, c.ok, dst, ok)
+		}
+	}
+
+	if got := DefaultTable.Events(StatePendingReview); !reflect.DeepEqual(got, []Event{EventApprove, EventReject}) {
+		t.Errorf("待审核状态下可执行的事件不正确: %v", got)
+	}
+	if got := DefaultTable.Events(StateRejected); len(got) != 0 {
+		t.Errorf("审核拒绝是终止状态，实际可执行: %v", got)
+	}
+}
+
+func TestTableValidate(t *testing.T) {
+	cases := map[string]struct {
+		table Table
+		want  string
+	}{
+		"未声明的状态": {
+			table: Table{Initial: StatePendingReview, States: []State{StatePendingReview}, Transitions: []Transition{
+				{Event: EventApprove, Src: []State{StatePendingReview}, Dst: StateApproved},
+			}},
+			want: `目标状态 "approved" 未声明`,
+		},
+		"同一事件多个目标": {
+			table: Table{Initial: StatePendingReview, States: []State{StatePendingReview, StateApproved, StateRejected}, Transitions: []Transition{
+				{Event: EventApprove, Src: []State{StatePendingReview}, Dst: StateApproved},
+				{Event: EventApprove, Src: []State{StatePendingReview}, Dst: StateRejected},
+			}},
+			want: "有多个目标",
+		},
+		"不可到达的状态": {
+			table: Table{Initial: StatePendingReview, States: []State{StatePendingReview, StateApproved, StateOverdue}, Transitions: []Transition{
+				{Event: EventApprove, Src: []State{StatePendingReview}, Dst: StateApproved},
+			}},
+			want: `状态 "overdue" 无法从初始状态到达`,
+		},
+	}
+	for name, c := range cases {
+		err := c.table.Validate()
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: 期望错误包含 %q，实际: %v", name, c.want, err)
+		}
+	}
+}
+
+func TestUserTransitions(t *testing.T) {
+	ctx := context.Background()
+
+	var changes []Change
+	user, err := NewUser("111", "Daniel", StatePendingReview, &Options{
+		OnTransition: func(ctx context.Context, c Change) { changes = append(changes, c) },
+	})
+	if err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+
+	steps := []struct {
+		fire func(context.Context) error
+		want State
+	}{
+		{user.Approve, StateApproved},
+		{user.MarkInactiveAfter30d, StateInactive},
+		{user.MarkOverdueAfter90d, StateOverdue},
+		{user.OnConsume, StateActive},
+	}
+	for _, step := range steps {
+		if err := step.fire(ctx); err != nil {
+			t.Fatalf("状态转换失败: %v", err)
+		}
+		if user.State != step.want {
+			t.Fatalf("期望状态 %s，实际: %s", step.want, user.State)
+		}
+	}
+	if len(changes) != len(steps) || changes[0] != (Change{UserID: "111", Event: EventApprove, From: StatePendingReview, To: StateApproved}) {
+		t.Errorf("状态转换通知不正确: %+v", changes)
+	}
+
+	// 非法转换不改变状态
+	if err := user.Approve(ctx); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("期望 ErrInvalidTransition，实际: %v", err)
+	}
+	if user.State != StateActive || len(changes) != len(steps) {
+		t.Errorf("非法转换不应改变状态: %s", user.State)
+	}
+
+	if _, err := NewUser("222", "Alice", State("deleted"), nil); err == nil {
+		t.Error("未知状态应创建失败")
+	}
+}
@@ -0,0 +1,75 @@
+package lifecycle
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// DefaultRedisPrefix 未指定前缀时 RedisStateStore 的 key 前缀
+const DefaultRedisPrefix = "lifecycle:user:"
+
+// RedisClient 状态存储依赖的 Redis 命令，redis.UniversalClient 都满足该接口
+type RedisClient interface {
+	redis.Scripter
+	HMGet(ctx context.Context, key string, fields ...string) *redis.SliceCmd
+}
+
+var _ RedisClient = redis.UniversalClient(nil)
+
+// 比较并保存：KEYS[1] 哈希中的版本（不存在为 0）等于 ARGV[1] 时写入状态 ARGV[2] 并把版本加 1
+var casScript = redis.NewScript(`
+	local version = tonumber(redis.call("hget", KEYS[1], "version") or "0")
+	if version ~= tonumber(ARGV[1]) then
+		return 0
+	end
+	redis.call("hset", KEYS[1], "state", ARGV[2], "version", version + 1)
+	return 1
+`)
+
+// RedisStateStore 基于 Redis 哈希的状态存储，每个用户一个 key（前缀 + 用户ID），不过期
+type RedisStateStore struct {
+	rdb    RedisClient
+	prefix string
+}
+
+var _ StateStore = (*RedisStateStore)(nil)
+
+// NewRedisStateStore 基于 Redis 连接创建状态存储，prefix 为空时使用 DefaultRedisPrefix
+func NewRedisStateStore(rdb RedisClient, prefix string) *RedisStateStore {
+	if prefix == "" {
+		prefix = DefaultRedisPrefix
+	}
+	return &RedisStateStore{rdb: rdb, prefix: prefix}
+}
+
+// Load 返回用户的状态和版本
+func (s *RedisStateStore) Load(ctx context.Context, userID string) (State, int64, error) {
+	var rec struct {
+		State   string `redis:"state"`
+		Version int64  `redis:"version"`
+	}
+	cmd := s.rdb.HMGet(ctx, s.prefix+userID, "state", "version")
+	if err := cmd.Err(); err != nil {
+		return "", 0, err
+	}
+	if cmd.Val()[0] == nil {
+		return "", 0, ErrNotFound
+	}
+	if err := cmd.Scan(&rec); err != nil {
+		return "", 0, err
+	}
+	return State(rec.State), rec.Version, nil
+}
+
+// CompareAndSwap 版本一致时保存状态
+func (s *RedisStateStore) CompareAndSwap(ctx context.Context, userID string, version int64, state State) error {
+	res, err := casScript.Run(ctx, s.rdb, []string{s.prefix + userID}, version, string(state)).Int()
+	if err != nil {
+		return err
+	}
+	if res != 1 {
+		return ErrConflict
+	}
+	return nil
+}
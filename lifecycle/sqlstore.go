@@ -0,0 +1,95 @@
+package lifecycle
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// DefaultSQLTable 未指定表名时 SQLStateStore 使用的表
+const DefaultSQLTable = "user_lifecycle_states"
+
+// SQLStateStore 基于数据库表的状态存储，每个用户一行。语句使用 ? 占位符，适用于 MySQL 和 SQLite
+type SQLStateStore struct {
+	db    *sql.DB
+	table string
+}
+
+var _ StateStore = (*SQLStateStore)(nil)
+
+// sqlTableName 表名只允许字母、数字和下划线，可以带库名前缀（如 app.user_lifecycle_states）
+var sqlTableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// NewSQLStateStore 基于数据库连接创建状态存储，table 为空时使用 DefaultSQLTable。
+// 表名无法作为语句参数传递，不是合法的标识符时 panic
+func NewSQLStateStore(db *sql.DB, table string) *SQLStateStore {
+	if table == "" {
+		table = DefaultSQLTable
+	}
+	if !sqlTableName.MatchString(table) {
+		panic(fmt.Sprintf("lifecycle: 无效的表名 %q", table))
+	}
+	return &SQLStateStore{db: db, table: table}
+}
+
+// CreateTable 创建状态表（已存在时跳过），表结构见
+// database-design-table-test/case1_community_forum/schema.sql
+func (s *SQLStateStore) CreateTable(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+  user_id VARCHAR(64) NOT NULL,
+  state VARCHAR(32) NOT NULL,
+  version BIGINT NOT NULL,
+  PRIMARY KEY (user_id)
+)`, s.table))
+	return err
+}
+
+// Load 返回用户的状态和版本
+func (s *SQLStateStore) Load(ctx context.Context, userID string) (State, int64, error) {
+	var (
+		state   string
+		version int64
+	)
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf(
+		"SELECT state, version FROM %s WHERE user_id = ?", s.table), userID).Scan(&state, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", 0, ErrNotFound
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	return State(state), version, nil
+}
+
+// CompareAndSwap 版本一致时保存状态：新建时插入，否则按版本条件更新
+func (s *SQLStateStore) CompareAndSwap(ctx context.Context, userID string, version int64, state State) error {
+	if version == 0 {
+		_, insertErr := s.db.ExecContext(ctx, fmt.Sprintf(
+			"INSERT INTO %s (user_id, state, version) VALUES (?, ?, 1)", s.table), userID, string(state))
+		if insertErr == nil {
+			return nil
+		}
+		// 用户已有记录说明其他实例抢先创建了该用户，按版本冲突处理；否则原样返回插入错误
+		if _, _, err := s.Load(ctx, userID); err == nil {
+			return ErrConflict
+		}
+		return insertErr
+	}
+
+	res, err := s.db.ExecContext(ctx, fmt.Sprintf(
+		"UPDATE %s SET state = ?, version = version + 1 WHERE user_id = ? AND version = ?", s.table),
+		string(state), userID, version)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return ErrConflict
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrConflict 保存状态时存储中的版本与预期不一致：状态已被其他实例修改
+	ErrConflict = errors.New("lifecycle: 状态版本冲突")
+	// ErrNotFound 存储中没有该用户的状态
+	ErrNotFound = errors.New("lifecycle: 用户状态不存在")
+)
+
+// StateStore 用户状态的持久化存储，通过版本号实现乐观并发控制。
+// 版本号从 1 开始，每次保存加 1；尚未保存过的用户版本为 0
+type StateStore interface {
+	// Load 返回用户的状态和版本，不存在时返回 ErrNotFound
+	Load(ctx context.Context, userID string) (State, int64, error)
+	// CompareAndSwap 仅当存储中的版本等于 version 时保存 state 并把版本加 1，
+	// version 为 0 表示新建。版本不一致时返回 ErrConflict
+	CompareAndSwap(ctx context.Context, userID string, version int64, state State) error
+}
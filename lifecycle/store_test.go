@@ -0,0 +1,137 @@
+package lifecycle
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"goRedisLock/internal/redistest"
+)
+
+// testUserID 状态存储测试使用的用户，Redis 中对应 testRedisPrefix + testUserID
+const (
+	testUserID      = "111"
+	testRedisPrefix = "test_lifecycle:user:"
+)
+
+// stateStores 按名称创建待测的 StateStore：Redis 测试结束时删除用户的 key，
+// SQL 使用临时目录中的 SQLite 文件，与 MySQL 执行相同的语句
+var stateStores = map[string]func(t *testing.T) StateStore{
+	"redis": func(t *testing.T) StateStore {
+		server := redistest.New(t, testRedisPrefix+testUserID)
+		return NewRedisStateStore(server.Client, testRedisPrefix)
+	},
+	"sqlite": func(t *testing.T) StateStore {
+		db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "states.db"))
+		if err != nil {
+			t.Fatalf("打开 SQLite 失败: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		store := NewSQLStateStore(db, "")
+		if err := store.CreateTable(context.Background()); err != nil {
+			t.Fatalf("创建状态表失败: %v", err)
+		}
+		return store
+	},
+}
+
+func TestStateStoreCompareAndSwap(t *testing.T) {
+	for name, newStore := range stateStores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t)
+
+			if _, _, err := store.Load(ctx, testUserID); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("期望 ErrNotFound，实际: %v", err)
+			}
+			if err := store.CompareAndSwap(ctx, testUserID, 0, StatePendingReview); err != nil {
+				t.Fatalf("新建状态失败: %v", err)
+			}
+			if err := store.CompareAndSwap(ctx, testUserID, 0, StatePendingReview); !errors.Is(err, ErrConflict) {
+				t.Errorf("重复新建应返回 ErrConflict，实际: %v", err)
+			}
+			if err := store.CompareAndSwap(ctx, testUserID, 1, StateApproved); err != nil {
+				t.Fatalf("保存状态失败: %v", err)
+			}
+			if err := store.CompareAndSwap(ctx, testUserID, 1, StateRejected); !errors.Is(err, ErrConflict) {
+				t.Errorf("过期版本应返回 ErrConflict，实际: %v", err)
+			}
+
+			state, version, err := store.Load(ctx, testUserID)
+			if err != nil || state != StateApproved || version != 2 {
+				t.Errorf("期望 approved 版本 2，实际: %s, %d, %v", state, version, err)
+			}
+		})
+	}
+}
+
+// 两个实例同时修改同一个用户，后提交的一方冲突且内存状态不变
+func TestUserTransitionConflict(t *testing.T) {
+	for name, newStore := range stateStores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			opts := &Options{Store: newStore(t)}
+
+			creator, err := NewUser(testUserID, "Daniel", StatePendingReview, opts)
+			if err != nil {
+				t.Fatalf("创建用户失败: %v", err)
+			}
+			if err := creator.Approve(ctx); err != nil {
+				t.Fatalf("审核通过失败: %v", err)
+			}
+
+			a, err := LoadUser(ctx, testUserID, "Daniel", opts)
+			if err != nil {
+				t.Fatalf("加载用户失败: %v", err)
+			}
+			b, err := LoadUser(ctx, testUserID, "Daniel", opts)
+			if err != nil {
+				t.Fatalf("加载用户失败: %v", err)
+			}
+			if a.State != StateApproved || a.Version != 1 {
+				t.Fatalf("期望 approved 版本 1，实际: %s, %d", a.State, a.Version)
+			}
+
+			if err := a.MarkInactiveAfter30d(ctx); err != nil {
+				t.Fatalf("状态转换失败: %v", err)
+			}
+			if err := b.MarkInactiveAfter30d(ctx); !errors.Is(err, ErrConflict) {
+				t.Fatalf("期望 ErrConflict，实际: %v", err)
+			}
+			if b.State != StateApproved || b.Version != 1 {
+				t.Errorf("冲突时内存状态不应改变: %s, %d", b.State, b.Version)
+			}
+
+			// 重新加载后基于最新状态继续转换
+			if err := b.Reload(ctx); err != nil {
+				t.Fatalf("重新加载失败: %v", err)
+			}
+			if err := b.MarkOverdueAfter90d(ctx); err != nil {
+				t.Fatalf("重新加载后状态转换失败: %v", err)
+			}
+			if state, version, _ := opts.Store.Load(ctx, testUserID); state != StateOverdue || version != 3 {
+				t.Errorf("期望 overdue 版本 3，实际: %s, %d", state, version)
+			}
+		})
+	}
+}
+
+func TestSQLStateStoreRejectsInvalidTable(t *testing.T) {
+	for _, table := range []string{"states; DROP TABLE users", "user-states", "a.b.c"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("表名 %q 不合法，应 panic", table)
+				}
+			}()
+			NewSQLStateStore(nil, table)
+		}()
+	}
+	// 带库名前缀的表名是合法的
+	NewSQLStateStore(nil, "app.user_lifecycle_states")
+}
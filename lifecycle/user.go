@@ -0,0 +1,172 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/looplab/fsm"
+)
+
+// Change 一次成功的状态转换
+type Change struct {
+	UserID string
+	Event  Event
+	From   State
+	To     State
+}
+
+// Options 用户状态机的配置项
+type Options struct {
+	// Table 状态转换表，默认 DefaultTable
+	Table *Table
+	// OnTransition 每次进入新状态后调用，例如发送审核通过通知
+	OnTransition func(ctx context.Context, c Change)
+	// Store 状态存储，为空时只在内存中转换。设置后每次转换先按版本保存新状态，
+	// 存储接受后才修改内存中的状态
+	Store StateStore
+}
+
+// User 用户聚合：用户字段和按转换表驱动的状态机，不能并发使用
+type User struct {
+	ID   string
+	Name string
+	// ... 其他用户字段
+	State   State // 当前状态，用于持久化
+	Version int64 // State 在存储中的版本，尚未保存过时为 0
+
+	table        Table
+	fsm          *fsm.FSM
+	onTransition func(ctx context.Context, c Change)
+	store        StateStore
+}
+
+// NewUser 创建处于 state 状态的用户，state 不在转换表中时返回错误
+func NewUser(id, name string, state State, opts *Options) (*User, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	table := DefaultTable
+	if o.Table != nil {
+		table = *o.Table
+	}
+	if !table.Has(state) {
+		return nil, fmt.Errorf("lifecycle: 未知的用户状态 %q", state)
+	}
+
+	u := &User{
+		ID:           id,
+		Name:         name,
+		State:        state,
+		table:        table,
+		onTransition: o.OnTransition,
+		store:        o.Store,
+	}
+
+	events := make(fsm.Events, 0, len(table.Transitions))
+	for _, tr := range table.Transitions {
+		src := make([]string, len(tr.Src))
+		for i, s := range tr.Src {
+			src[i] = string(s)
+		}
+		events = append(events, fsm.EventDesc{Name: string(tr.Event), Src: src, Dst: string(tr.Dst)})
+	}
+	u.fsm = fsm.NewFSM(string(state), events, fsm.Callbacks{
+		// 每次成功进入新状态后同步 State 字段
+		"enter_state": func(ctx context.Context, e *fsm.Event) {
+			u.State = State(e.Dst)
+			if u.onTransition != nil {
+				u.onTransition(ctx, Change{UserID: u.ID, Event: Event(e.Event), From: State(e.Src), To: State(e.Dst)})
+			}
+		},
+	})
+	return u, nil
+}
+
+// LoadUser 从 opts.Store 加载用户的状态和版本，存储中没有该用户时返回 ErrNotFound
+func LoadUser(ctx context.Context, id, name string, opts *Options) (*User, error) {
+	if opts == nil || opts.Store == nil {
+		return nil, errors.New("lifecycle: LoadUser 需要设置 Options.Store")
+	}
+	state, version, err := opts.Store.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	u, err := NewUser(id, name, state, opts)
+	if err != nil {
+		return nil, err
+	}
+	u.Version = version
+	return u, nil
+}
+
+// Fire 执行事件，当前状态下不允许该事件时返回 ErrInvalidTransition。
+// 设置了 Store 时先按当前版本保存新状态，版本冲突时返回 ErrConflict，
+// 保存失败时内存中的状态保持不变，可以 Reload 后重试
+func (u *User) Fire(ctx context.Context, event Event) error {
+	dst, ok := u.table.Next(u.State, event)
+	if !ok {
+		return fmt.Errorf("%w: 用户 %s 处于 %s 状态，不能执行 %s", ErrInvalidTransition, u.ID, u.State, event)
+	}
+	if u.store != nil {
+		if err := u.store.CompareAndSwap(ctx, u.ID, u.Version, dst); err != nil {
+			return fmt.Errorf("lifecycle: 保存用户 %s 的状态 %s（版本 %d）失败: %w", u.ID, dst, u.Version, err)
+		}
+		u.Version++
+	}
+	return u.fsm.Event(ctx, string(event))
+}
+
+// Reload 从存储重新加载状态和版本，用于版本冲突后重试
+func (u *User) Reload(ctx context.Context) error {
+	if u.store == nil {
+		return errors.New("lifecycle: 未设置 Options.Store")
+	}
+	state, version, err := u.store.Load(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	if !u.table.Has(state) {
+		return fmt.Errorf("lifecycle: 未知的用户状态 %q", state)
+	}
+	u.State, u.Version = state, version
+	u.fsm.SetState(string(state))
+	return nil
+}
+
+// Can 判断当前状态下能否执行事件
+func (u *User) Can(event Event) bool {
+	_, ok := u.table.Next(u.State, event)
+	return ok
+}
+
+// AvailableEvents 返回当前状态下可以执行的事件
+func (u *User) AvailableEvents() []Event {
+	return u.table.Events(u.State)
+}
+
+// Approve 审核通过
+func (u *User) Approve(ctx context.Context) error {
+	return u.Fire(ctx, EventApprove)
+}
+
+// Reject 审核拒绝
+func (u *User) Reject(ctx context.Context) error {
+	return u.Fire(ctx, EventReject)
+}
+
+// MarkInactiveAfter30d 定时任务检测到30天无消耗时调用
+func (u *User) MarkInactiveAfter30d(ctx context.Context) error {
+	return u.Fire(ctx, EventNoConsume30d)
+}
+
+// MarkOverdueAfter90d 定时任务检测到90天无消耗时调用
+func (u *User) MarkOverdueAfter90d(ctx context.Context) error {
+	return u.Fire(ctx, EventNoConsume90d)
+}
+
+// OnConsume 用户消费时调用
+func (u *User) OnConsume(ctx context.Context) error {
+	return u.Fire(ctx, EventConsume)
+}
@@ -0,0 +1,45 @@
+package lock_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"goRedisLock/internal/lockload"
+	"goRedisLock/internal/redistest"
+	"goRedisLock/lock"
+)
+
+// BenchmarkLock 对比三种获取方式在不同竞争程度下的吞吐量和获取延迟：
+//
+//	go test -bench=Lock -run=^$ ./lock
+//
+// ns/op 为每次获取/释放的平均耗时，另外报告 ops/s 以及获取延迟的 p50/p99（微秒）
+func BenchmarkLock(b *testing.B) {
+	ctx := context.Background()
+	for _, mode := range lockload.Modes {
+		for _, goroutines := range []int{1, 10, 100} {
+			b.Run(fmt.Sprintf("%s/goroutines=%d", mode, goroutines), func(b *testing.B) {
+				server := redistest.New(b, "bench_lock", lock.FenceKey("bench_lock"))
+				client := lock.NewClient(server.Client)
+
+				b.ResetTimer()
+				r, err := lockload.Run(ctx, client, lockload.Config{
+					Mode:       mode,
+					Goroutines: goroutines,
+					Ops:        b.N,
+					Key:        "bench_lock",
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+				if r.Errors > 0 {
+					b.Fatalf("%d 次获取/释放失败", r.Errors)
+				}
+				b.ReportMetric(r.OpsPerSec, "ops/s")
+				b.ReportMetric(float64(r.P50.Microseconds()), "p50-µs")
+				b.ReportMetric(float64(r.P99.Microseconds()), "p99-µs")
+			})
+		}
+	}
+}
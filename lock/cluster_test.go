@@ -0,0 +1,93 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"goRedisLock/internal/redistest"
+)
+
+func TestClusterLocks(t *testing.T) {
+	ctx := context.Background()
+	server := redistest.NewCluster(t)
+	client := NewClient(server.Cluster)
+
+	// 互斥锁：SET 与 fencing token 计数器在同一个脚本中
+	first := client.NewLock("cluster:mutex", &Options{TTL: 5 * time.Second})
+	if err := first.Lock(ctx); err != nil {
+		t.Fatalf("集群中获取锁失败: %v", err)
+	}
+	if first.Token() != 1 {
+		t.Errorf("fencing token 应为 1，实际: %d", first.Token())
+	}
+	if err := first.Unlock(ctx); err != nil {
+		t.Fatalf("集群中释放锁失败: %v", err)
+	}
+
+	// 读写锁：锁 key、读者集合和写意向在同一个脚本中
+	rw := client.NewRWLock("cluster:rw", &Options{TTL: 5 * time.Second, Retry: NoRetry()})
+	if err := rw.RLock(ctx); err != nil {
+		t.Fatalf("集群中获取读锁失败: %v", err)
+	}
+	writer := client.NewRWLock("cluster:rw", &Options{TTL: 5 * time.Second, Retry: NoRetry()})
+	if err := writer.Lock(ctx); !errors.Is(err, ErrNotObtained) {
+		t.Errorf("有读者时写锁应获取失败，实际: %v", err)
+	}
+	if err := rw.RUnlock(ctx); err != nil {
+		t.Fatalf("集群中释放读锁失败: %v", err)
+	}
+	if err := writer.Lock(ctx); err != nil {
+		t.Errorf("读者离开后写锁应获取成功: %v", err)
+	}
+
+	// 多 key 锁：相同 hash tag 的 key 可以原子获取，不同 slot 的 key 直接拒绝
+	m := client.NewMultiLock([]string{"{order:42}:stock", "{order:42}:payment"}, &Options{Retry: NoRetry()})
+	if err := m.Lock(ctx); err != nil {
+		t.Fatalf("集群中获取多 key 锁失败: %v", err)
+	}
+	if err := m.Unlock(ctx); err != nil {
+		t.Fatalf("集群中释放多 key 锁失败: %v", err)
+	}
+	cross := client.NewMultiLock([]string{"order:42", "order:43"}, &Options{Retry: NoRetry()})
+	if err := cross.Lock(ctx); !errors.Is(err, ErrCrossSlot) {
+		t.Errorf("不同 slot 的 key 应返回 ErrCrossSlot，实际: %v", err)
+	}
+
+	infos, err := List(ctx, server.Cluster, "cluster:")
+	if err != nil {
+		t.Fatalf("集群中列出锁失败: %v", err)
+	}
+	if len(infos) != 2 || infos[0].Key != "cluster:mutex" || infos[1].Key != "cluster:rw" || infos[1].Kind != "mutex" {
+		t.Errorf("集群中列出的锁不正确: %+v", infos)
+	}
+}
+
+func TestClusterWakeOnRelease(t *testing.T) {
+	ctx := context.Background()
+	server := redistest.NewCluster(t)
+	client := NewClient(server.Cluster)
+
+	holder := client.NewLock("cluster:wake", &Options{TTL: 5 * time.Second})
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+
+	// 兜底轮询间隔远大于释放时间，只有收到释放通知才能及时获取
+	waiter := client.NewLock("cluster:wake", &Options{TTL: 5 * time.Second, Retry: FixedBackoff(time.Minute), WakeOnRelease: true})
+	done := make(chan error, 1)
+	go func() {
+		waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		done <- waiter.Lock(waitCtx)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	if err := holder.Unlock(ctx); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("集群中等待者应被释放通知唤醒: %v", err)
+	}
+}
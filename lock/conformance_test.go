@@ -0,0 +1,321 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"goRedisLock/internal/redistest"
+)
+
+// conformanceLock 各存储实现的锁都提供的方法
+type conformanceLock interface {
+	Locker
+	Token() int64
+	Lost() <-chan struct{}
+}
+
+// lockBackend 一种锁存储：newLock 创建锁，advance 让存储中的时间前进 d
+type lockBackend struct {
+	newLock func(key string, opts *Options) conformanceLock
+	advance func(d time.Duration)
+}
+
+// fakeClock 在真实时间上叠加偏移，advance 后锁的过期判断立即生效，同时看门狗仍按真实时间续期
+type fakeClock struct {
+	offset int64
+}
+
+func (c *fakeClock) now() time.Time {
+	return time.Now().Add(time.Duration(atomic.LoadInt64(&c.offset)))
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	atomic.AddInt64(&c.offset, int64(d))
+}
+
+// conformanceKey 一致性测试各场景使用的锁 key，每个场景结束时释放
+const conformanceKey = "test_conformance_lock"
+
+// 每个存储实现都要通过的用例，SQLite 代替 MySQL
+var lockBackends = map[string]func(t *testing.T) lockBackend{
+	"redis": func(t *testing.T) lockBackend {
+		// 连接真实 Redis 时各场景共用同一个 key，测试结束时连同 fencing token 计数器一起清理
+		server := redistest.New(t, conformanceKey, FenceKey(conformanceKey))
+		client := NewClient(server.Client)
+		return lockBackend{
+			newLock: func(key string, opts *Options) conformanceLock { return client.NewLock(key, opts) },
+			advance: server.FastForward,
+		}
+	},
+	"memory": func(t *testing.T) lockBackend {
+		var clock fakeClock
+		store := NewMemoryStore()
+		store.now = clock.now
+		return lockBackend{
+			newLock: func(key string, opts *Options) conformanceLock { return store.NewLock(key, opts) },
+			advance: clock.advance,
+		}
+	},
+	"sqlite": func(t *testing.T) lockBackend {
+		dsn := "file:" + filepath.Join(t.TempDir(), "locks.db") + "?_pragma=busy_timeout(5000)"
+		db, err := sql.Open("sqlite", dsn)
+		if err != nil {
+			t.Fatalf("打开 SQLite 失败: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		var clock fakeClock
+		store := NewSQLStore(db, "")
+		store.now = clock.now
+		if err := store.CreateTable(context.Background()); err != nil {
+			t.Fatalf("创建锁表失败: %v", err)
+		}
+		return lockBackend{
+			newLock: func(key string, opts *Options) conformanceLock { return store.NewLock(key, opts) },
+			advance: clock.advance,
+		}
+	},
+}
+
+// 按 lock_test.go 的场景逐个验证各存储实现
+func TestLockConformance(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, b lockBackend){
+		"Concurrency":    func(t *testing.T, b lockBackend) { testMutualExclusion(t, b, 10) },
+		"RaceCondition":  func(t *testing.T, b lockBackend) { testMutualExclusion(t, b, 20) },
+		"Safety":         testLockSafety,
+		"Timeout":        testLockTimeout,
+		"Renewal":        testLockRenewal,
+		"RefreshAndTTL":  testLockRefreshAndTTL,
+		"FencingToken":   testLockFencingToken,
+		"BlockUntilFree": testLockBlocksUntilReleased,
+	}
+	for name, newBackend := range lockBackends {
+		t.Run(name, func(t *testing.T) {
+			for scenario, run := range scenarios {
+				t.Run(scenario, func(t *testing.T) {
+					run(t, newBackend(t))
+				})
+			}
+		})
+	}
+}
+
+// 多个 goroutine 同时竞争，只有一个能获取锁
+func testMutualExclusion(t *testing.T, b lockBackend, n int) {
+	ctx := context.Background()
+
+	var (
+		acquired  int32
+		attempted sync.WaitGroup
+		wg        sync.WaitGroup
+	)
+	start := make(chan struct{})
+	attempted.Add(n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			locker := b.newLock(conformanceKey, &Options{TTL: 5 * time.Second})
+
+			<-start
+			ok, err := locker.TryLock(ctx)
+			attempted.Done()
+			if err != nil {
+				t.Errorf("获取锁出错: %v", err)
+				return
+			}
+			if !ok {
+				return
+			}
+			atomic.AddInt32(&acquired, 1)
+
+			// 所有 goroutine 都尝试过之后再释放，避免释放后被其他 goroutine 再次获取
+			attempted.Wait()
+			if err := locker.Unlock(ctx); err != nil {
+				t.Errorf("释放锁失败: %v", err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if acquired != 1 {
+		t.Errorf("期望只有1个goroutine能获取锁，实际有%d个", acquired)
+	}
+}
+
+// 只有持有者才能释放锁
+func testLockSafety(t *testing.T, b lockBackend) {
+	ctx := context.Background()
+
+	locker := b.newLock(conformanceKey, &Options{TTL: 10 * time.Second})
+	if ok, err := locker.TryLock(ctx); err != nil || !ok {
+		t.Fatalf("获取锁失败: %v, %v", ok, err)
+	}
+
+	wrong := b.newLock(conformanceKey, &Options{Value: "wrong_value"})
+	if err := wrong.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("用错误值释放锁应返回 ErrLockNotHeld，实际: %v", err)
+	}
+	if err := locker.Unlock(ctx); err != nil {
+		t.Errorf("用正确值释放锁失败: %v", err)
+	}
+}
+
+// 锁到期后自动释放，原持有者无法再释放或续期
+func testLockTimeout(t *testing.T, b lockBackend) {
+	ctx := context.Background()
+
+	locker := b.newLock(conformanceKey, &Options{TTL: 2 * time.Second})
+	if ok, err := locker.TryLock(ctx); err != nil || !ok {
+		t.Fatalf("获取锁失败: %v, %v", ok, err)
+	}
+	b.advance(3 * time.Second)
+
+	if ttl, err := locker.TTL(ctx); err != nil || ttl != 0 {
+		t.Errorf("过期后 TTL 应为 0，实际: %v, %v", ttl, err)
+	}
+	if err := locker.Refresh(ctx, 0); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("过期后续期应返回 ErrLockNotHeld，实际: %v", err)
+	}
+	if err := locker.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("释放已过期的锁应返回 ErrLockNotHeld，实际: %v", err)
+	}
+	other := b.newLock(conformanceKey, &Options{Retry: NoRetry()})
+	if err := other.Lock(ctx); err != nil {
+		t.Fatalf("锁过期后其他持有者应获取成功: %v", err)
+	}
+	if err := other.Unlock(ctx); err != nil {
+		t.Errorf("释放锁失败: %v", err)
+	}
+}
+
+// 持有时间超过 TTL 时由看门狗续期
+func testLockRenewal(t *testing.T, b lockBackend) {
+	ctx := context.Background()
+
+	locker := b.newLock(conformanceKey, &Options{
+		TTL:           300 * time.Millisecond,
+		AutoRenew:     true,
+		RenewInterval: 50 * time.Millisecond,
+	})
+	if err := locker.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+
+	other := b.newLock(conformanceKey, nil)
+	for i := 0; i < 5; i++ {
+		select {
+		case <-locker.Lost():
+			t.Fatalf("第%d次检查：续期失败，锁已丢失", i+1)
+		case <-time.After(200 * time.Millisecond):
+		}
+		if ok, err := other.TryLock(ctx); err != nil || ok {
+			t.Fatalf("第%d次检查：锁应仍被持有: %v, %v", i+1, ok, err)
+		}
+	}
+
+	if err := locker.Unlock(ctx); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+}
+
+// 续期和查询剩余时间只对持有者生效
+func testLockRefreshAndTTL(t *testing.T, b lockBackend) {
+	ctx := context.Background()
+
+	locker := b.newLock(conformanceKey, &Options{TTL: 2 * time.Second})
+	if err := locker.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+
+	other := b.newLock(conformanceKey, nil)
+	if err := other.Refresh(ctx, time.Minute); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("期望 ErrLockNotHeld，实际: %v", err)
+	}
+	if ttl, err := other.TTL(ctx); err != nil || ttl != 0 {
+		t.Errorf("非持有者的 TTL 应为 0，实际: %v, %v", ttl, err)
+	}
+
+	if err := locker.Refresh(ctx, time.Minute); err != nil {
+		t.Fatalf("续期失败: %v", err)
+	}
+	ttl, err := locker.TTL(ctx)
+	if err != nil {
+		t.Fatalf("查询 TTL 失败: %v", err)
+	}
+	if ttl <= 2*time.Second || ttl > time.Minute {
+		t.Errorf("续期后 TTL 不正确: %v", ttl)
+	}
+
+	if err := locker.Unlock(ctx); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+	if err := locker.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("重复释放应返回 ErrLockNotHeld，实际: %v", err)
+	}
+}
+
+// fencing token 在释放和过期接管后都单调递增，获取失败不消耗 token
+func testLockFencingToken(t *testing.T, b lockBackend) {
+	ctx := context.Background()
+
+	first := b.newLock(conformanceKey, &Options{TTL: 2 * time.Second})
+	if err := first.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+	if err := first.Unlock(ctx); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+
+	second := b.newLock(conformanceKey, &Options{TTL: 2 * time.Second})
+	if err := second.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+	loser := b.newLock(conformanceKey, &Options{Retry: NoRetry()})
+	if err := loser.Lock(ctx); !errors.Is(err, ErrNotObtained) {
+		t.Fatalf("期望 ErrNotObtained，实际: %v", err)
+	}
+	if loser.Token() != 0 {
+		t.Errorf("获取失败时 token 应为 0，实际为 %d", loser.Token())
+	}
+
+	b.advance(3 * time.Second)
+	third := b.newLock(conformanceKey, &Options{Retry: NoRetry()})
+	if err := third.Lock(ctx); err != nil {
+		t.Fatalf("锁过期后获取失败: %v", err)
+	}
+	defer third.Unlock(ctx)
+	if first.Token() <= 0 || second.Token() <= first.Token() || third.Token() <= second.Token() {
+		t.Errorf("fencing token 应单调递增: %d -> %d -> %d", first.Token(), second.Token(), third.Token())
+	}
+}
+
+// Lock 阻塞直到持有者释放
+func testLockBlocksUntilReleased(t *testing.T, b lockBackend) {
+	ctx := context.Background()
+
+	holder := b.newLock(conformanceKey, &Options{TTL: 5 * time.Second})
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+	time.AfterFunc(100*time.Millisecond, func() { holder.Unlock(ctx) })
+
+	waiter := b.newLock(conformanceKey, &Options{Retry: FixedBackoff(20 * time.Millisecond)})
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if err := waiter.Lock(ctx); err != nil {
+		t.Fatalf("持有者释放后应获取成功: %v", err)
+	}
+	if err := waiter.Unlock(ctx); err != nil {
+		t.Errorf("释放锁失败: %v", err)
+	}
+}
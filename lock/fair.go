@@ -0,0 +1,190 @@
+package lock
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// FairLock 按先来先得顺序获取的公平锁。
+// Lock 获取失败的等待者排入 Redis 列表，释放锁时直接移交给队首的等待者，
+// 后来者即使恰好在锁空闲的瞬间重试也无法插队。
+// 等待者每次重试都会延长自己的期限（Options.WaiterTimeout），进程崩溃或放弃等待的等待者
+// 超过期限后被移出队列；移交给已消失等待者的锁也只保留一个期限，不会阻塞后面的等待者。
+// 已持有锁时再次 TryLock 会返回 true 并重置过期时间
+type FairLock struct {
+	client        *Client
+	key           string
+	value         string
+	ttl           time.Duration
+	retry         RetryStrategy
+	waiterTimeout time.Duration
+
+	wakeOnRelease bool
+	watchdog      *watchdog
+	obs           *observation
+
+	token int64 // 最近一次获取锁得到的 fencing token
+}
+
+var _ Locker = (*FairLock)(nil)
+
+// NewFairLock 创建指定 key 的公平锁，此时并不会去获取锁
+func (c *Client) NewFairLock(key string, opts *Options) *FairLock {
+	o := opts.withDefaults()
+	l := &FairLock{
+		client:        c,
+		key:           key,
+		value:         o.Value,
+		ttl:           o.TTL,
+		retry:         o.Retry,
+		waiterTimeout: o.WaiterTimeout,
+		wakeOnRelease: o.WakeOnRelease,
+		obs:           newObservation(o, key),
+	}
+	l.watchdog = newWatchdog(o, l.Refresh, l.obs)
+	return l
+}
+
+// Key 返回锁的 key
+func (l *FairLock) Key() string {
+	return l.key
+}
+
+// Value 返回锁持有者标识
+func (l *FairLock) Value() string {
+	return l.value
+}
+
+// Token 返回最近一次获取锁得到的 fencing token，尚未获取过锁时返回 0
+func (l *FairLock) Token() int64 {
+	return atomic.LoadInt64(&l.token)
+}
+
+// Lock 排队阻塞获取锁，直到获取成功、重试策略放弃或 ctx 结束；放弃时退出等待队列。
+// 重试间隔不会超过等待者期限的 1/3，避免等待中被当作已消失
+func (l *FairLock) Lock(ctx context.Context) error {
+	return l.obs.lock(ctx, func() error {
+		return l.lock(ctx)
+	})
+}
+
+func (l *FairLock) lock(ctx context.Context) error {
+	var wake <-chan *redis.Message
+	if l.wakeOnRelease {
+		sub, ch, err := subscribeRelease(ctx, l.client.rdb, l.key)
+		if err != nil {
+			return err
+		}
+		defer sub.Close()
+		wake = ch
+	}
+
+	retry := cappedBackoff{s: l.retry, max: l.waiterTimeout / 3}
+	err := retryLoop(ctx, retry, wake, func() (bool, error) {
+		return l.acquire(ctx, true)
+	})
+	if err != nil {
+		l.leave(context.WithoutCancel(ctx))
+	}
+	return err
+}
+
+// TryLock 尝试获取一次锁，不排入等待队列：队列中有等待者时，即使锁空闲也获取失败
+func (l *FairLock) TryLock(ctx context.Context) (bool, error) {
+	return l.obs.tryLock(ctx, func() (bool, error) {
+		return l.acquire(ctx, false)
+	})
+}
+
+// acquire 获取一次锁，enqueue 为 true 时获取失败后排入等待队列（已在队列中时延长期限）
+func (l *FairLock) acquire(ctx context.Context, enqueue bool) (bool, error) {
+	flag := 0
+	if enqueue {
+		flag = 1
+	}
+	token, err := fairAcquireScript.Run(ctx, l.client.rdb, l.keys(FenceKey(l.key)),
+		l.value, l.ttl.Milliseconds(), l.waiterTimeout.Milliseconds(), flag).Int64()
+	if err != nil || token == 0 {
+		return false, err
+	}
+	atomic.StoreInt64(&l.token, token)
+	l.watchdog.start(ctx)
+	return true, nil
+}
+
+// Unlock 释放锁，有等待者时移交给队首
+func (l *FairLock) Unlock(ctx context.Context) error {
+	l.watchdog.stop()
+
+	res, err := fairReleaseScript.Run(ctx, l.client.rdb, l.keys(),
+		l.value, ReleaseChannel(l.key), l.waiterTimeout.Milliseconds()).Int64()
+	if err == nil && res != 1 {
+		err = ErrLockNotHeld
+	}
+	return l.obs.unlock(ctx, err)
+}
+
+// Refresh 延长锁的过期时间
+func (l *FairLock) Refresh(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = l.ttl
+	}
+	res, err := refreshScript.Run(ctx, l.client.rdb, []string{l.key}, l.value, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if res != 1 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// TTL 返回锁的剩余过期时间
+func (l *FairLock) TTL(ctx context.Context) (time.Duration, error) {
+	res, err := pttlScript.Run(ctx, l.client.rdb, []string{l.key}, l.value).Int64()
+	if err != nil {
+		return 0, err
+	}
+	if res <= 0 {
+		return 0, nil
+	}
+	return time.Duration(res) * time.Millisecond, nil
+}
+
+// Lost 返回锁丢失信号：看门狗续期失败时关闭，未开启 AutoRenew 时永远不会关闭
+func (l *FairLock) Lost() <-chan struct{} {
+	return l.watchdog.Lost()
+}
+
+// QueueLen 返回等待队列的长度
+func (l *FairLock) QueueLen(ctx context.Context) (int64, error) {
+	return l.client.rdb.LLen(ctx, QueueKey(l.key)).Result()
+}
+
+// leave 退出等待队列；放弃前锁恰好已移交给自己时，继续移交给下一个等待者
+func (l *FairLock) leave(ctx context.Context) {
+	fairDequeueScript.Run(ctx, l.client.rdb, []string{QueueKey(l.key), QueueTimeoutKey(l.key)}, l.value)
+	fairReleaseScript.Run(ctx, l.client.rdb, l.keys(), l.value, ReleaseChannel(l.key), l.waiterTimeout.Milliseconds())
+}
+
+// keys 返回公平锁脚本使用的 key：锁、等待队列、等待者期限，以及 extra
+func (l *FairLock) keys(extra ...string) []string {
+	return append([]string{l.key, QueueKey(l.key), QueueTimeoutKey(l.key)}, extra...)
+}
+
+// cappedBackoff 限制重试策略的最大等待时间
+type cappedBackoff struct {
+	s   RetryStrategy
+	max time.Duration
+}
+
+func (s cappedBackoff) Backoff(attempt int) time.Duration {
+	d := s.s.Backoff(attempt)
+	if d > s.max {
+		d = s.max
+	}
+	return d
+}
@@ -0,0 +1,196 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"goRedisLock/internal/redistest"
+)
+
+func fairTestKeys(key string) []string {
+	return []string{key, FenceKey(key), QueueKey(key), QueueTimeoutKey(key)}
+}
+
+func TestFairLockFIFO(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, fairTestKeys("test_fair_lock")...)
+
+	newLock := func(name string) *FairLock {
+		return client.NewFairLock("test_fair_lock", &Options{Value: name, TTL: 5 * time.Second})
+	}
+	holder := newLock("holder")
+	if ok, err := holder.TryLock(ctx); err != nil || !ok {
+		t.Fatalf("获取锁失败: %v, %v", ok, err)
+	}
+
+	// 依次排队（acquire(ctx, true) 相当于 Lock 的一次重试）
+	waiters := []*FairLock{newLock("a"), newLock("b"), newLock("c")}
+	for _, w := range waiters {
+		if ok, _ := w.acquire(ctx, true); ok {
+			t.Fatalf("%s 不应获取成功", w.Value())
+		}
+	}
+	if n, _ := holder.QueueLen(ctx); n != 3 {
+		t.Fatalf("队列长度应为 3，实际: %d", n)
+	}
+
+	if err := holder.Unlock(ctx); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+	// 锁已移交给队首，后来者无法插队
+	if ok, _ := newLock("latecomer").TryLock(ctx); ok {
+		t.Error("后来者不应插队获取锁")
+	}
+
+	for i, w := range waiters {
+		// 队首之后的等待者都获取不到
+		for _, other := range waiters[i+1:] {
+			if ok, _ := other.TryLock(ctx); ok {
+				t.Fatalf("%s 排在 %s 之后，不应先获取", other.Value(), w.Value())
+			}
+		}
+		if ok, err := w.TryLock(ctx); err != nil || !ok {
+			t.Fatalf("%s 应按顺序获取锁: %v, %v", w.Value(), ok, err)
+		}
+		if err := w.Unlock(ctx); err != nil {
+			t.Fatalf("%s 释放锁失败: %v", w.Value(), err)
+		}
+	}
+}
+
+func TestFairLockVanishedWaiter(t *testing.T) {
+	ctx := context.Background()
+	server := redistest.New(t, fairTestKeys("test_fair_vanish_lock")...)
+	client := NewClient(server.Client)
+
+	newLock := func(name string) *FairLock {
+		return client.NewFairLock("test_fair_vanish_lock", &Options{Value: name, TTL: 5 * time.Second, WaiterTimeout: 100 * time.Millisecond})
+	}
+	holder, gone, alive := newLock("holder"), newLock("gone"), newLock("alive")
+	if ok, _ := holder.TryLock(ctx); !ok {
+		t.Fatal("获取锁失败")
+	}
+	gone.acquire(ctx, true)
+	alive.acquire(ctx, true)
+
+	// gone 不再重试，超过期限后被移出队列；alive 继续重试保持存活
+	time.Sleep(150 * time.Millisecond)
+	alive.acquire(ctx, true)
+	if err := holder.Unlock(ctx); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+	if ok, err := alive.TryLock(ctx); err != nil || !ok {
+		t.Fatalf("消失的等待者应被跳过: %v, %v", ok, err)
+	}
+
+	// 锁移交给已消失的等待者后，只保留一个等待者期限
+	crashed := newLock("crashed")
+	crashed.acquire(ctx, true)
+	if err := alive.Unlock(ctx); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+	next := newLock("next")
+	if ok, _ := next.TryLock(ctx); ok {
+		t.Fatal("锁已移交给 crashed，不应获取成功")
+	}
+	server.FastForward(200 * time.Millisecond)
+	if ok, err := next.TryLock(ctx); err != nil || !ok {
+		t.Fatalf("移交给已消失等待者的锁过期后应获取成功: %v, %v", ok, err)
+	}
+}
+
+// TryLock 失败不排队，锁不会移交给已经离开的调用方
+func TestFairLockTryLockDoesNotQueue(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, fairTestKeys("test_fair_trylock")...)
+
+	holder := client.NewFairLock("test_fair_trylock", nil)
+	if ok, err := holder.TryLock(ctx); err != nil || !ok {
+		t.Fatalf("获取锁失败: %v, %v", ok, err)
+	}
+	probe := client.NewFairLock("test_fair_trylock", nil)
+	if ok, _ := probe.TryLock(ctx); ok {
+		t.Fatal("锁被占用时 TryLock 不应成功")
+	}
+	if n, _ := holder.QueueLen(ctx); n != 0 {
+		t.Fatalf("TryLock 失败后不应排队，队列长度: %d", n)
+	}
+	if err := holder.Unlock(ctx); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+
+	next := client.NewFairLock("test_fair_trylock", nil)
+	if ok, err := next.TryLock(ctx); err != nil || !ok {
+		t.Fatalf("锁释放后应获取成功: %v, %v", ok, err)
+	}
+	if err := next.Unlock(ctx); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+}
+
+func TestFairLockGiveUp(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, fairTestKeys("test_fair_giveup_lock")...)
+
+	holder := client.NewFairLock("test_fair_giveup_lock", nil)
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+	waiter := client.NewFairLock("test_fair_giveup_lock", nil)
+	waitCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if err := waiter.Lock(waitCtx); !errors.Is(err, ErrNotObtained) {
+		t.Fatalf("等待超时应返回 ErrNotObtained，实际: %v", err)
+	}
+	if n, _ := waiter.QueueLen(ctx); n != 0 {
+		t.Errorf("放弃等待后应退出队列，队列长度: %d", n)
+	}
+	if err := holder.Unlock(ctx); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+}
+
+func TestFairLockConcurrent(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, fairTestKeys("test_fair_concurrent_lock")...)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		holders int
+	)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l := client.NewFairLock("test_fair_concurrent_lock", &Options{WakeOnRelease: true})
+			for n := 0; n < 3; n++ {
+				waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+				err := l.Lock(waitCtx)
+				cancel()
+				if err != nil {
+					t.Errorf("获取锁失败: %v", err)
+					return
+				}
+				mu.Lock()
+				holders++
+				if holders > 1 {
+					t.Error("同一时间有多个持有者")
+				}
+				mu.Unlock()
+				time.Sleep(2 * time.Millisecond)
+				mu.Lock()
+				holders--
+				mu.Unlock()
+				if err := l.Unlock(ctx); err != nil {
+					t.Errorf("释放锁失败: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
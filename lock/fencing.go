@@ -0,0 +1,61 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+)
+
+// ErrStaleToken 写入携带的 fencing token 比已接受过的更旧，
+// 说明写入方持有的锁已过期并被其他持有者重新获取
+var ErrStaleToken = errors.New("lock: fencing token 已过期")
+
+// FenceGuard 在内存中按资源记录已接受的最大 fencing token，
+// 拒绝携带更旧 token 的写入，适用于单实例的下游存储
+type FenceGuard struct {
+	mu     sync.Mutex
+	tokens map[string]int64
+}
+
+// NewFenceGuard 创建 FenceGuard
+func NewFenceGuard() *FenceGuard {
+	return &FenceGuard{tokens: make(map[string]int64)}
+}
+
+// Check 校验并记录 resource 的 fencing token，token 小于已接受的最大值时返回 ErrStaleToken。
+// 同一持有者可以用同一个 token 多次写入
+func (g *FenceGuard) Check(resource string, token int64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if token < g.tokens[resource] {
+		return ErrStaleToken
+	}
+	g.tokens[resource] = token
+	return nil
+}
+
+// Execer 执行 SQL 语句，*sql.DB 和 *sql.Tx 都满足该接口
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// FencedExec 执行带 fencing token 条件的更新语句，没有行被更新时返回 ErrStaleToken。
+// 语句需要在 WHERE 中比较 token 并同时写入新 token，例如更新帖子：
+//
+//	UPDATE posts SET content = ?, fence_token = ? WHERE id = ? AND fence_token <= ?
+func FencedExec(ctx context.Context, db Execer, query string, args ...any) error {
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrStaleToken
+	}
+	return nil
+}
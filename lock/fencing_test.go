@@ -0,0 +1,52 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFencingTokenIncreases(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, "test_fence_lock", FenceKey("test_fence_lock"))
+
+	first := client.NewLock("test_fence_lock", &Options{TTL: 5 * time.Second})
+	if err := first.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+	if err := first.Unlock(ctx); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+
+	second := client.NewLock("test_fence_lock", &Options{TTL: 5 * time.Second})
+	if err := second.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+	defer second.Unlock(ctx)
+
+	if first.Token() <= 0 || second.Token() <= first.Token() {
+		t.Fatalf("fencing token 应单调递增: %d -> %d", first.Token(), second.Token())
+	}
+
+	// 获取失败不应消耗 token
+	loser := client.NewLock("test_fence_lock", &Options{Retry: NoRetry()})
+	if err := loser.Lock(ctx); !errors.Is(err, ErrNotObtained) {
+		t.Fatalf("期望 ErrNotObtained，实际: %v", err)
+	}
+	if loser.Token() != 0 {
+		t.Errorf("获取失败时 token 应为 0，实际为 %d", loser.Token())
+	}
+
+	// 暂停后醒来的旧持有者携带旧 token 写入会被拒绝
+	guard := NewFenceGuard()
+	if err := guard.Check("post:1", second.Token()); err != nil {
+		t.Fatalf("新 token 写入失败: %v", err)
+	}
+	if err := guard.Check("post:1", first.Token()); !errors.Is(err, ErrStaleToken) {
+		t.Errorf("旧 token 写入应返回 ErrStaleToken，实际: %v", err)
+	}
+	if err := guard.Check("post:1", second.Token()); err != nil {
+		t.Errorf("同一 token 重复写入应成功: %v", err)
+	}
+}
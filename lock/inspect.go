@@ -0,0 +1,277 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Info 锁在 Redis 中的当前状态，供运维排查使用
+type Info struct {
+	Key        string
+	Kind       string        // mutex、fair（有排队等待者的公平锁）、reentrant、semaphore、rwlock，锁未被持有时为 free，不是锁时为 unknown
+	Owners     []string      // 持有者标识；可重入锁附带持有次数，读者附带 r: 前缀
+	TTL        time.Duration // 剩余过期时间，未设置过期时间时为 -1
+	FenceToken int64         // 最近一次发放的 fencing token
+	Waiters    int64         // 等待者数量：订阅了释放通知的等待者与公平锁等待队列长度中的较大值
+}
+
+// Inspect 读取 key 对应锁的当前状态
+func Inspect(ctx context.Context, rdb redis.Cmdable, key string) (*Info, error) {
+	info := &Info{Key: key, Kind: "free"}
+
+	kind, err := rdb.Type(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case "string":
+		info.Kind = "mutex"
+		owner, err := rdb.Get(ctx, key).Result()
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+		if owner != "" {
+			info.Owners = append(info.Owners, owner)
+		}
+	case "hash":
+		info.Kind = "reentrant"
+		holds, err := rdb.HGetAll(ctx, key).Result()
+		if err != nil {
+			return nil, err
+		}
+		for owner, count := range holds {
+			info.Owners = append(info.Owners, fmt.Sprintf("%s(x%s)", owner, count))
+		}
+		sort.Strings(info.Owners)
+	case "zset":
+		info.Kind = "semaphore"
+		holders, err := rdb.ZRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			return nil, err
+		}
+		info.Owners = append(info.Owners, holders...)
+	case "none":
+	default:
+		info.Kind = "unknown"
+		return info, nil
+	}
+	if kind != "none" {
+		if info.TTL, err = rdb.PTTL(ctx, key).Result(); err != nil {
+			return nil, err
+		}
+	}
+
+	// 读写锁的读者
+	readers, err := rdb.ZRange(ctx, ReadersKey(key), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(readers) > 0 {
+		info.Kind = "rwlock"
+		for _, r := range readers {
+			info.Owners = append(info.Owners, "r:"+r)
+		}
+		if info.TTL <= 0 {
+			if info.TTL, err = rdb.PTTL(ctx, ReadersKey(key)).Result(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	token, err := rdb.Get(ctx, FenceKey(key)).Int64()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	info.FenceToken = token
+
+	channel := ReleaseChannel(key)
+	subs, err := rdb.PubSubNumSub(ctx, channel).Result()
+	if err != nil {
+		return nil, err
+	}
+	info.Waiters = subs[channel]
+
+	// 公平锁的等待队列中还包括未订阅释放通知的等待者
+	queued, err := rdb.LLen(ctx, QueueKey(key)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if queued > 0 {
+		if info.Kind == "mutex" {
+			info.Kind = "fair"
+		}
+		if queued > info.Waiters {
+			info.Waiters = queued
+		}
+	}
+	return info, nil
+}
+
+// List 扫描 prefix 开头的 key，返回其中各个锁的状态（辅助 key 归并到所属的锁，跳过不是锁的 key）。
+// 连接 Redis Cluster 时扫描所有主节点
+func List(ctx context.Context, rdb redis.Cmdable, prefix string) ([]*Info, error) {
+	seen := make(map[string]bool)
+	var keys []string
+	var mu sync.Mutex
+	collect := func(batch []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, k := range batch {
+			base := BaseKey(k)
+			if !seen[base] && strings.HasPrefix(base, prefix) {
+				seen[base] = true
+				keys = append(keys, base)
+			}
+		}
+	}
+
+	// 辅助 key 以 { 开头，需要单独匹配（只有读者持有的读写锁没有锁 key 本身）
+	patterns := []string{prefix + "*", "{" + prefix + "*"}
+	scanNode := func(ctx context.Context, node redis.Cmdable) error {
+		for _, pattern := range patterns {
+			if err := scanKeys(ctx, node, pattern, collect); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var err error
+	if cluster, ok := rdb.(*redis.ClusterClient); ok {
+		err = cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return scanNode(ctx, node)
+		})
+	} else {
+		err = scanNode(ctx, rdb)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+
+	infos := make([]*Info, 0, len(keys))
+	for _, k := range keys {
+		info, err := Inspect(ctx, rdb, k)
+		if err != nil {
+			return nil, err
+		}
+		if info.Kind != "unknown" {
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+// scanKeys 用 SCAN 遍历匹配 pattern 的 key
+func scanKeys(ctx context.Context, rdb redis.Cmdable, pattern string, fn func([]string)) error {
+	var cursor uint64
+	for {
+		batch, next, err := rdb.Scan(ctx, cursor, pattern, 100).Result()
+		if err != nil {
+			return err
+		}
+		fn(batch)
+		cursor = next
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// ForceRelease 不校验持有者强制释放锁，并通知等待者；返回释放前的状态。
+// 读取状态与删除在同一个 Lua 脚本中完成，返回的持有者就是实际被释放的持有者。
+// fencing token 计数器会保留，保证之后发放的 token 仍然单调递增。
+// key 不是锁时不做修改，返回 Kind 为 unknown 的状态。
+// 仅供运维处理持有者崩溃等异常情况使用
+func ForceRelease(ctx context.Context, rdb redis.Cmdable, key string) (*Info, error) {
+	channel := ReleaseChannel(key)
+	subs, err := rdb.PubSubNumSub(ctx, channel).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{key, ReadersKey(key), WriterWaitingKey(key), FenceKey(key), QueueKey(key)}
+	res, err := forceReleaseScript.Run(ctx, rdb, keys, channel).Slice()
+	if err != nil {
+		return nil, err
+	}
+	info := &Info{Key: key, Kind: "unknown"}
+	if len(res) < 7 {
+		return info, nil
+	}
+	kind, _ := res[0].(string)
+	owners := toStrings(res[2])
+	switch kind {
+	case "string":
+		info.Kind = "mutex"
+		info.Owners = owners
+	case "hash":
+		info.Kind = "reentrant"
+		for i := 0; i+1 < len(owners); i += 2 {
+			info.Owners = append(info.Owners, fmt.Sprintf("%s(x%s)", owners[i], owners[i+1]))
+		}
+		sort.Strings(info.Owners)
+	case "zset":
+		info.Kind = "semaphore"
+		info.Owners = owners
+	default:
+		info.Kind = "free"
+	}
+	if kind != "none" {
+		info.TTL = pttl(toInt64(res[1]))
+	}
+
+	if readers := toStrings(res[3]); len(readers) > 0 {
+		info.Kind = "rwlock"
+		for _, r := range readers {
+			info.Owners = append(info.Owners, "r:"+r)
+		}
+		if info.TTL <= 0 {
+			info.TTL = pttl(toInt64(res[4]))
+		}
+	}
+	info.FenceToken = toInt64(res[5])
+
+	info.Waiters = subs[channel]
+	if queued := toInt64(res[6]); queued > 0 {
+		if info.Kind == "mutex" {
+			info.Kind = "fair"
+		}
+		if queued > info.Waiters {
+			info.Waiters = queued
+		}
+	}
+	return info, nil
+}
+
+// toStrings 把脚本返回的数组转换为字符串切片
+func toStrings(v interface{}) []string {
+	items, _ := v.([]interface{})
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// pttl 把 PTTL 的毫秒数转换为 Duration，-1（未设置过期时间）和 -2（key 不存在）
+// 与 go-redis 的 PTTL 一样原样保留，与 Inspect 返回的 TTL 一致
+func pttl(ms int64) time.Duration {
+	if ms < 0 {
+		return time.Duration(ms)
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// toInt64 把脚本返回的整数转换为 int64
+func toInt64(v interface{}) int64 {
+	n, _ := v.(int64)
+	return n
+}
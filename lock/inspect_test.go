@@ -0,0 +1,100 @@
+package lock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"goRedisLock/internal/redistest"
+)
+
+func TestInspectAndForceRelease(t *testing.T) {
+	ctx := context.Background()
+	server := redistest.New(t, "jobs:report", FenceKey("jobs:report"), "jobs:config", ReadersKey("jobs:config"), "jobs:audit")
+	client := NewClient(server.Client)
+
+	crashed := client.NewLock("jobs:report", &Options{TTL: time.Minute, Value: "worker_1"})
+	if err := crashed.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+	reader := client.NewRWLock("jobs:config", &Options{TTL: time.Minute, Value: "reader_1"})
+	if err := reader.RLock(ctx); err != nil {
+		t.Fatalf("获取读锁失败: %v", err)
+	}
+
+	// 不是锁的 key 不会被列出
+	server.Client.LPush(ctx, "jobs:audit", "record")
+
+	infos, err := List(ctx, server.Client, "jobs:")
+	if err != nil {
+		t.Fatalf("列出锁失败: %v", err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("期望 2 个锁，实际 %d 个", len(infos))
+	}
+	config, report := infos[0], infos[1]
+	if config.Key != "jobs:config" || config.Kind != "rwlock" || len(config.Owners) != 1 || config.Owners[0] != "r:reader_1" {
+		t.Errorf("读写锁状态不正确: %+v", config)
+	}
+	if report.Key != "jobs:report" || report.Kind != "mutex" || report.Owners[0] != "worker_1" {
+		t.Errorf("互斥锁状态不正确: %+v", report)
+	}
+	if report.TTL <= 0 || report.FenceToken != crashed.Token() {
+		t.Errorf("TTL 或 fencing token 不正确: %+v", report)
+	}
+
+	before, err := ForceRelease(ctx, server.Client, "jobs:report")
+	if err != nil {
+		t.Fatalf("强制释放失败: %v", err)
+	}
+	if before.Kind != "mutex" || len(before.Owners) != 1 || before.Owners[0] != "worker_1" || before.FenceToken != crashed.Token() {
+		t.Errorf("强制释放应返回释放前的持有者，实际: %+v", before)
+	}
+
+	// 不是锁的 key 不会被删除
+	other, err := ForceRelease(ctx, server.Client, "jobs:audit")
+	if err != nil {
+		t.Fatalf("强制释放失败: %v", err)
+	}
+	if other.Kind != "unknown" || server.Client.Exists(ctx, "jobs:audit").Val() != 1 {
+		t.Errorf("不是锁的 key 不应被强制释放: %+v", other)
+	}
+
+	readers, err := ForceRelease(ctx, server.Client, "jobs:config")
+	if err != nil {
+		t.Fatalf("强制释放失败: %v", err)
+	}
+	if readers.Kind != "rwlock" || len(readers.Owners) != 1 || readers.Owners[0] != "r:reader_1" {
+		t.Errorf("强制释放应返回释放前的读者，实际: %+v", readers)
+	}
+	if server.Client.Exists(ctx, ReadersKey("jobs:config")).Val() != 0 {
+		t.Errorf("强制释放后读者集合应被删除")
+	}
+
+	next := client.NewLock("jobs:report", &Options{Retry: NoRetry()})
+	if err := next.Lock(ctx); err != nil {
+		t.Fatalf("强制释放后应获取成功: %v", err)
+	}
+	if next.Token() <= crashed.Token() {
+		t.Errorf("强制释放后 fencing token 仍应递增: %d -> %d", crashed.Token(), next.Token())
+	}
+}
+
+// 没有过期时间的锁，Inspect 和 ForceRelease 都返回 -1
+func TestForceReleaseWithoutExpiry(t *testing.T) {
+	ctx := context.Background()
+	server := redistest.New(t, "jobs:forever")
+	server.Client.Set(ctx, "jobs:forever", "worker_1", 0)
+
+	info, err := Inspect(ctx, server.Client, "jobs:forever")
+	if err != nil {
+		t.Fatalf("查看锁失败: %v", err)
+	}
+	before, err := ForceRelease(ctx, server.Client, "jobs:forever")
+	if err != nil {
+		t.Fatalf("强制释放失败: %v", err)
+	}
+	if info.TTL != -1 || before.TTL != -1 {
+		t.Errorf("没有过期时间时 TTL 应为 -1，Inspect: %v，ForceRelease: %v", info.TTL, before.TTL)
+	}
+}
@@ -0,0 +1,119 @@
+package lock
+
+import "strings"
+
+// 锁在 Redis 中的 key 布局。除锁本身的 key 外，各类锁会用到以下辅助 key：
+//
+//	{<key>}:fence           fencing token 计数器（RedisLock）
+//	{<key>}:readers         读者租约有序集合（RWLock）
+//	{<key>}:writer_waiting  等待中的写者（RWLock）
+//	{<key>}:queue           等待队列（FairLock）
+//	{<key>}:queue_timeout   等待者期限有序集合（FairLock）
+//	{<key>}:released        锁释放通知的 Pub/Sub 频道
+//
+// 辅助 key 用锁 key 作为 hash tag，在 Redis Cluster 中与锁 key 落在同一个 slot，
+// 多 key 的 Lua 脚本才能执行。锁 key 本身含有花括号时直接追加后缀：带有 hash tag
+// （如 order:{42}）时仍在同一个 slot；花括号不构成 hash tag 时（如 a}b）无法保证，
+// 集群中不要使用这类 key。x 与 {x} 的辅助 key 相同，不要同时用二者作为不同的锁。
+const (
+	fenceSuffix         = ":fence"
+	readersSuffix       = ":readers"
+	writerWaitingSuffix = ":writer_waiting"
+	queueSuffix         = ":queue"
+	queueTimeoutSuffix  = ":queue_timeout"
+	releasedSuffix      = ":released"
+)
+
+// FenceKey 锁的 fencing token 计数器，不设置过期时间以保证单调递增
+func FenceKey(key string) string {
+	return auxKey(key, fenceSuffix)
+}
+
+// ReadersKey 读写锁的读者租约有序集合
+func ReadersKey(key string) string {
+	return auxKey(key, readersSuffix)
+}
+
+// WriterWaitingKey 读写锁中登记等待中写者的 key
+func WriterWaitingKey(key string) string {
+	return auxKey(key, writerWaitingSuffix)
+}
+
+// QueueKey 公平锁的等待队列
+func QueueKey(key string) string {
+	return auxKey(key, queueSuffix)
+}
+
+// QueueTimeoutKey 公平锁中记录各等待者期限的有序集合
+func QueueTimeoutKey(key string) string {
+	return auxKey(key, queueTimeoutSuffix)
+}
+
+// ReleaseChannel 锁释放通知的频道名
+func ReleaseChannel(key string) string {
+	return auxKey(key, releasedSuffix)
+}
+
+// BaseKey 返回辅助 key 所属的锁 key，不是辅助 key 时原样返回
+func BaseKey(key string) string {
+	for _, suffix := range []string{fenceSuffix, readersSuffix, writerWaitingSuffix, queueSuffix, queueTimeoutSuffix} {
+		if !strings.HasSuffix(key, suffix) {
+			continue
+		}
+		base := strings.TrimSuffix(key, suffix)
+		if strings.HasPrefix(base, "{") && strings.HasSuffix(base, "}") {
+			if inner := base[1 : len(base)-1]; !strings.ContainsAny(inner, "{}") {
+				return inner
+			}
+		}
+		return base
+	}
+	return key
+}
+
+// auxKey 生成与锁 key 处于同一 hash slot 的辅助 key
+func auxKey(key, suffix string) string {
+	if strings.ContainsAny(key, "{}") {
+		return key + suffix
+	}
+	return "{" + key + "}" + suffix
+}
+
+// sameSlot 判断 keys 是否都落在同一个 hash slot，少于两个 key 时总是成立
+func sameSlot(keys []string) bool {
+	if len(keys) < 2 {
+		return true
+	}
+	for _, k := range keys[1:] {
+		if keySlot(k) != keySlot(keys[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+// hashTag 返回 key 参与 slot 计算的部分：第一个 { 与其后第一个 } 之间非空时只取二者之间的内容
+func hashTag(key string) string {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			return key[start+1 : start+1+end]
+		}
+	}
+	return key
+}
+
+// keySlot 返回 key 在 Redis Cluster 中的 hash slot（CRC16 XMODEM 对 16384 取模）
+func keySlot(key string) int {
+	var crc uint16
+	for _, b := range []byte(hashTag(key)) {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return int(crc) % 16384
+}
@@ -0,0 +1,79 @@
+package lock
+
+import "testing"
+
+func TestAuxKeysShareSlot(t *testing.T) {
+	tests := []struct {
+		key      string
+		fence    string
+		sameSlot bool
+	}{
+		{"mylock", "{mylock}:fence", true},
+		{"order:{42}", "order:{42}:fence", true},
+		{"a}b", "a}b:fence", false}, // 花括号不构成 hash tag，无法保证同一个 slot
+	}
+	for _, tt := range tests {
+		if got := FenceKey(tt.key); got != tt.fence {
+			t.Errorf("FenceKey(%q) = %q，期望 %q", tt.key, got, tt.fence)
+		}
+		for _, aux := range []string{FenceKey(tt.key), ReadersKey(tt.key), WriterWaitingKey(tt.key), QueueKey(tt.key), QueueTimeoutKey(tt.key)} {
+			if tt.sameSlot && keySlot(aux) != keySlot(tt.key) {
+				t.Errorf("辅助 key %q 与锁 key %q 不在同一个 slot", aux, tt.key)
+			}
+			if base := BaseKey(aux); base != tt.key {
+				t.Errorf("BaseKey(%q) = %q，期望 %q", aux, base, tt.key)
+			}
+		}
+	}
+	if BaseKey("mylock") != "mylock" {
+		t.Error("不是辅助 key 时应原样返回")
+	}
+}
+
+// 同一把锁用到的所有 key（含释放通知频道）都要落在锁 key 所在的 slot
+func TestLockKeysShareSlot(t *testing.T) {
+	for _, key := range []string{"mylock", "jobs:nightly", "order:{42}", "{user1}:profile"} {
+		want := keySlot(key)
+		keys := []string{
+			FenceKey(key), ReadersKey(key), WriterWaitingKey(key),
+			QueueKey(key), QueueTimeoutKey(key), ReleaseChannel(key),
+		}
+		for _, k := range keys {
+			if got := keySlot(k); got != want {
+				t.Errorf("%q 的 slot 为 %d，锁 key %q 的 slot 为 %d", k, got, key, want)
+			}
+		}
+	}
+}
+
+func TestKeySlot(t *testing.T) {
+	// 与 Redis CLUSTER KEYSLOT 的结果一致
+	tests := map[string]int{
+		"123456789":   12739,
+		"foo":         12182,
+		"bar":         5061,
+		"{user1}:a":   keySlot("user1"),
+		"foo{}{bar}":  keySlot("foo{}{bar}"),
+		"foo{{bar}}x": keySlot("{bar"),
+	}
+	for key, want := range tests {
+		if got := keySlot(key); got != want {
+			t.Errorf("keySlot(%q) = %d，期望 %d", key, got, want)
+		}
+	}
+	if keySlot("foo{}{bar}") == keySlot("bar") {
+		t.Error("空 hash tag 应按整个 key 计算 slot")
+	}
+}
+
+func TestSameSlot(t *testing.T) {
+	if !sameSlot(nil) || !sameSlot([]string{"foo"}) {
+		t.Error("少于两个 key 时应视为同一个 slot")
+	}
+	if !sameSlot([]string{"{user1}:a", "{user1}:b"}) {
+		t.Error("hash tag 相同的 key 应落在同一个 slot")
+	}
+	if sameSlot([]string{"foo", "bar"}) {
+		t.Error("foo 和 bar 不在同一个 slot")
+	}
+}
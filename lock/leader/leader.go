@@ -0,0 +1,228 @@
+// Package leader 基于 Redis 分布式锁的 leader 选举，保证定时任务等只在一个实例上运行。
+//
+// 候选者通过获取同一把锁竞选 leader，当选后由看门狗自动续期；续期失败（锁过期或被强制释放）、
+// 主动 Resign 或 ctx 结束时卸任。典型用法：
+//
+//	e := leader.New(lock.NewClient(rdb), "jobs:inactivity-check", &leader.Options{
+//		OnElected: func(ctx context.Context) { runJobs(ctx) }, // 卸任时 ctx 被取消
+//	})
+//	go e.Run(ctx) // 持续参选，卸任后自动重新参选
+package leader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"goRedisLock/internal/randid"
+	"goRedisLock/lock"
+)
+
+// DefaultTTL 未指定时 leader 租约的过期时间
+const DefaultTTL = 15 * time.Second
+
+// Options 选举配置
+type Options struct {
+	// ID 候选者标识（即锁的持有者标识），默认随机生成；不同实例不能使用相同的 ID
+	ID string
+	// TTL leader 租约过期时间，leader 崩溃后最多经过 TTL 选出新 leader，默认 DefaultTTL
+	TTL time.Duration
+	// RenewInterval 续期间隔，默认 TTL/3
+	RenewInterval time.Duration
+	// RetryInterval 未当选时重新竞选的兜底间隔（同时订阅卸任通知），默认 TTL/3
+	RetryInterval time.Duration
+
+	// OnElected 当选时在新的 goroutine 中调用，ctx 在卸任时取消。
+	// 卸任会等待 OnElected 返回，ctx 取消后应尽快返回
+	OnElected func(ctx context.Context)
+	// OnDemoted 卸任时调用，此时锁已释放，OnElected 已经返回
+	OnDemoted func()
+}
+
+// Election 一个候选者参与的选举
+type Election struct {
+	client *lock.Client
+	key    string
+	opts   Options
+
+	leader atomic.Bool
+
+	mu         sync.Mutex
+	cancel     context.CancelFunc // 结束当前任期，不是 leader 时为 nil
+	done       chan struct{}      // 当前任期结束后关闭
+	releaseErr error              // 最近一次卸任时释放锁的错误
+	watchers   []chan bool
+}
+
+// New 创建 key 上的选举，此时并不会参选
+func New(client *lock.Client, key string, opts *Options) *Election {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.ID == "" {
+		o.ID = randid.New()
+	}
+	if o.TTL <= 0 {
+		o.TTL = DefaultTTL
+	}
+	if o.RenewInterval <= 0 {
+		o.RenewInterval = o.TTL / 3
+	}
+	if o.RetryInterval <= 0 {
+		o.RetryInterval = o.TTL / 3
+	}
+	return &Election{client: client, key: key, opts: o}
+}
+
+// ID 返回候选者标识
+func (e *Election) ID() string {
+	return e.opts.ID
+}
+
+// IsLeader 返回当前是否为 leader
+func (e *Election) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Observe 返回 leader 状态变化的通知：当选时收到 true，卸任时收到 false。
+// 接收不及时时只保留最新的状态
+func (e *Election) Observe() <-chan bool {
+	ch := make(chan bool, 1)
+	e.mu.Lock()
+	e.watchers = append(e.watchers, ch)
+	e.mu.Unlock()
+	return ch
+}
+
+// Campaign 阻塞竞选 leader，直到当选、ctx 结束或出错；已是 leader 时直接返回。
+// 当选后在后台保持领导权，直到 Resign、ctx 结束或租约丢失
+func (e *Election) Campaign(ctx context.Context) error {
+	if e.IsLeader() {
+		return nil
+	}
+
+	l := e.client.NewLock(e.key, &lock.Options{
+		TTL:           e.opts.TTL,
+		Value:         e.opts.ID,
+		Retry:         lock.FixedBackoff(e.opts.RetryInterval),
+		WakeOnRelease: true,
+		AutoRenew:     true,
+		RenewInterval: e.opts.RenewInterval,
+	})
+	if err := l.Lock(ctx); err != nil {
+		return err
+	}
+
+	termCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	e.mu.Lock()
+	e.cancel, e.done, e.releaseErr = cancel, done, nil
+	e.mu.Unlock()
+
+	e.setLeader(true)
+	elected := make(chan struct{})
+	go func() {
+		defer close(elected)
+		if e.opts.OnElected != nil {
+			e.opts.OnElected(termCtx)
+		}
+	}()
+	go e.hold(termCtx, l, elected, done)
+	return nil
+}
+
+// Resign 主动卸任并释放锁，等待卸任完成（包括 OnElected 和 OnDemoted 返回）；不是 leader 时直接返回
+func (e *Election) Resign(ctx context.Context) error {
+	e.mu.Lock()
+	cancel, done := e.cancel, e.done
+	e.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.releaseErr
+}
+
+// Run 持续参选直到 ctx 结束：当选后保持领导权，卸任后重新参选。
+// 竞选出错（如 Redis 连接失败）时等待 RetryInterval 后重试，返回 ctx 结束的原因
+func (e *Election) Run(ctx context.Context) error {
+	for {
+		if err := e.Campaign(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			select {
+			case <-time.After(e.opts.RetryInterval):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		e.mu.Lock()
+		done := e.done
+		e.mu.Unlock()
+		<-done
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// hold 保持领导权，直到租约丢失或任期被结束；卸任时等待 OnElected 返回（elected 关闭）
+// 后再调用 OnDemoted
+func (e *Election) hold(ctx context.Context, l *lock.RedisLock, elected, done chan struct{}) {
+	defer close(done)
+
+	var err error
+	select {
+	case <-l.Lost():
+		err = lock.ErrLockNotHeld
+	case <-ctx.Done():
+		// 调用方的 ctx 已结束时仍要释放锁，让其他候选者尽快当选
+		releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), e.opts.TTL)
+		err = l.Unlock(releaseCtx)
+		cancel()
+		if errors.Is(err, lock.ErrLockNotHeld) {
+			err = nil
+		}
+	}
+
+	e.mu.Lock()
+	e.cancel()
+	e.cancel, e.releaseErr = nil, err
+	e.mu.Unlock()
+
+	e.setLeader(false)
+	<-elected
+	if e.opts.OnDemoted != nil {
+		e.opts.OnDemoted()
+	}
+}
+
+// setLeader 更新 leader 状态并通知观察者
+func (e *Election) setLeader(leader bool) {
+	e.leader.Store(leader)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, ch := range e.watchers {
+		// 丢弃未被接收的旧状态
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- leader
+	}
+}
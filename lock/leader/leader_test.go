@@ -0,0 +1,167 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"goRedisLock/internal/redistest"
+	"goRedisLock/lock"
+)
+
+func TestElection(t *testing.T) {
+	ctx := context.Background()
+	server := redistest.New(t)
+	client := lock.NewClient(server.Client)
+
+	var elected, demoted atomic.Int32
+	termEnded := make(chan struct{})
+	a := New(client, "test_leader", &Options{
+		ID:  "a",
+		TTL: 5 * time.Second,
+		OnElected: func(ctx context.Context) {
+			elected.Add(1)
+			<-ctx.Done()
+			close(termEnded)
+		},
+		OnDemoted: func() { demoted.Add(1) },
+	})
+	b := New(client, "test_leader", &Options{ID: "b", TTL: 5 * time.Second, RetryInterval: time.Minute})
+	changes := a.Observe()
+
+	if err := a.Campaign(ctx); err != nil {
+		t.Fatalf("a 竞选失败: %v", err)
+	}
+	if !a.IsLeader() || !<-changes {
+		t.Fatal("a 应当选 leader")
+	}
+
+	// b 只能等待 a 卸任
+	campaignCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if err := b.Campaign(campaignCtx); !errors.Is(err, lock.ErrNotObtained) || b.IsLeader() {
+		t.Fatalf("a 在任期间 b 不应当选，实际: %v", err)
+	}
+
+	bElected := make(chan error, 1)
+	go func() { bElected <- b.Campaign(ctx) }()
+	time.Sleep(50 * time.Millisecond)
+
+	if err := a.Resign(ctx); err != nil {
+		t.Fatalf("a 卸任失败: %v", err)
+	}
+	if a.IsLeader() || <-changes {
+		t.Error("a 卸任后不应是 leader")
+	}
+	<-termEnded
+	if elected.Load() != 1 || demoted.Load() != 1 {
+		t.Errorf("回调次数不正确: 当选 %d 次，卸任 %d 次", elected.Load(), demoted.Load())
+	}
+
+	// b 订阅了卸任通知，不必等到兜底重试间隔
+	select {
+	case err := <-bElected:
+		if err != nil || !b.IsLeader() {
+			t.Fatalf("a 卸任后 b 应当选: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("a 卸任后 b 未及时当选")
+	}
+	if err := b.Resign(ctx); err != nil {
+		t.Fatalf("b 卸任失败: %v", err)
+	}
+}
+
+func TestElectionLeaseLost(t *testing.T) {
+	ctx := context.Background()
+	server := redistest.New(t)
+	client := lock.NewClient(server.Client)
+
+	termCanceled := make(chan struct{})
+	e := New(client, "test_leader_lost", &Options{
+		TTL:           3 * time.Second,
+		RenewInterval: 20 * time.Millisecond,
+		OnElected: func(ctx context.Context) {
+			<-ctx.Done()
+			close(termCanceled)
+		},
+	})
+	changes := e.Observe()
+	if err := e.Campaign(ctx); err != nil {
+		t.Fatalf("竞选失败: %v", err)
+	}
+	<-changes
+
+	// 锁被运维强制释放后由其他实例获取，续期失败即卸任
+	server.Client.Set(ctx, "test_leader_lost", "other", time.Minute)
+	select {
+	case leader := <-changes:
+		if leader {
+			t.Fatal("租约丢失后应卸任")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("租约丢失后未及时卸任")
+	}
+	<-termCanceled
+	if e.IsLeader() {
+		t.Error("租约丢失后不应是 leader")
+	}
+	if err := e.Resign(ctx); err != nil {
+		t.Errorf("已卸任时 Resign 应直接返回: %v", err)
+	}
+}
+
+func TestElectionRun(t *testing.T) {
+	server := redistest.New(t)
+	client := lock.NewClient(server.Client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e := New(client, "test_leader_run", &Options{TTL: 3 * time.Second})
+	changes := e.Observe()
+	done := make(chan error, 1)
+	go func() { done <- e.Run(ctx) }()
+
+	if !<-changes {
+		t.Fatal("应当选 leader")
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run 应返回 ctx 结束的原因，实际: %v", err)
+	}
+	if <-changes {
+		t.Error("ctx 结束后应卸任")
+	}
+	if server.Client.Exists(context.Background(), "test_leader_run").Val() != 0 {
+		t.Error("卸任后应释放锁")
+	}
+}
+
+// OnDemoted 在 OnElected 返回之后才调用
+func TestElectionDemotedAfterElectedReturns(t *testing.T) {
+	ctx := context.Background()
+	server := redistest.New(t)
+	client := lock.NewClient(server.Client)
+
+	var returned, returnedBeforeDemoted atomic.Bool
+	e := New(client, "test_leader_order", &Options{
+		TTL: 3 * time.Second,
+		OnElected: func(ctx context.Context) {
+			<-ctx.Done()
+			// 模拟收尾工作
+			time.Sleep(50 * time.Millisecond)
+			returned.Store(true)
+		},
+		OnDemoted: func() { returnedBeforeDemoted.Store(returned.Load()) },
+	})
+	if err := e.Campaign(ctx); err != nil {
+		t.Fatalf("竞选失败: %v", err)
+	}
+	if err := e.Resign(ctx); err != nil {
+		t.Fatalf("卸任失败: %v", err)
+	}
+	if !returnedBeforeDemoted.Load() {
+		t.Error("OnDemoted 应在 OnElected 返回后调用")
+	}
+}
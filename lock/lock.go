@@ -0,0 +1,272 @@
+// Package lock 提供分布式锁，默认基于 Redis，也提供进程内（MemoryStore）和数据库表（SQLStore）的实现
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"goRedisLock/internal/randid"
+)
+
+// DefaultTTL 未指定过期时间时使用的锁过期时间
+const DefaultTTL = 30 * time.Second
+
+// DefaultWaiterTimeout 未指定时公平锁等待者的存活期限
+const DefaultWaiterTimeout = 5 * time.Second
+
+// DefaultNodeTimeout Redlock 单个节点请求超时的下限
+const DefaultNodeTimeout = 50 * time.Millisecond
+
+// DefaultRetry 未指定重试策略时 Lock 使用的重试策略
+var DefaultRetry = ExponentialBackoff(16*time.Millisecond, 512*time.Millisecond)
+
+var (
+	// ErrNotObtained 锁已被其他持有者占用，获取失败
+	ErrNotObtained = errors.New("lock: 锁未获取")
+	// ErrLockNotHeld 当前持有者已不再持有该锁（已过期或被其他进程获取）
+	ErrLockNotHeld = errors.New("lock: 锁未持有")
+)
+
+// Locker 分布式锁的通用接口
+type Locker interface {
+	// Lock 阻塞获取锁，直到获取成功、重试策略放弃或 ctx 结束，失败时返回 ErrNotObtained
+	Lock(ctx context.Context) error
+	// TryLock 尝试获取一次锁，返回是否获取成功
+	TryLock(ctx context.Context) (bool, error)
+	// Unlock 释放锁，只有锁的持有者才能释放，否则返回 ErrLockNotHeld
+	Unlock(ctx context.Context) error
+	// Refresh 延长锁的过期时间，ttl<=0 时使用获取锁时的过期时间
+	Refresh(ctx context.Context, ttl time.Duration) error
+	// TTL 返回锁的剩余过期时间，锁未被当前持有者持有时返回 0
+	TTL(ctx context.Context) (time.Duration, error)
+}
+
+// Options 锁的配置项
+type Options struct {
+	TTL   time.Duration // 锁过期时间，默认 DefaultTTL
+	Value string        // 锁持有者标识，为空时随机生成
+
+	// Retry Lock 获取失败时的重试策略，默认 DefaultRetry
+	Retry RetryStrategy
+	// WakeOnRelease Lock 等待期间订阅锁释放通知，收到通知后立即重试，
+	// 重试策略作为兜底轮询
+	WakeOnRelease bool
+
+	// AutoRenew 获取锁后启动看门狗，定期延长锁的过期时间直到 Unlock 或 ctx 取消。
+	// 获取锁用的 ctx 比持有期间更短（如带超时的等待）时，传入 context.WithoutCancel(ctx)
+	// 让看门狗只随 Unlock 停止
+	AutoRenew bool
+	// RenewInterval 看门狗续期间隔，默认 TTL/3
+	RenewInterval time.Duration
+
+	// Observer 锁事件观察者，默认忽略所有事件
+	Observer Observer
+
+	// WaiterTimeout 公平锁中等待者的存活期限：超过该时间未再重试的等待者视为已消失，
+	// 从队列中移除；移交给已消失等待者的锁也在该时间后过期。默认 DefaultWaiterTimeout
+	WaiterTimeout time.Duration
+
+	// NodeTimeout Redlock 中单个节点的请求超时，应远小于 TTL，避免在宕机节点上阻塞。
+	// 默认取 TTL 的 1%，且不少于 DefaultNodeTimeout
+	NodeTimeout time.Duration
+}
+
+// RedisClient 锁依赖的 Redis 命令。redis.UniversalClient 都满足该接口，
+// 包括单机的 *redis.Client、哨兵的 redis.NewFailoverClient 和集群的 *redis.ClusterClient；
+// 集群中锁的辅助 key 使用 hash tag 与锁 key 落在同一个 slot，见 FenceKey
+type RedisClient interface {
+	redis.Scripter
+	HGet(ctx context.Context, key, field string) *redis.StringCmd
+	ZCard(ctx context.Context, key string) *redis.IntCmd
+	LLen(ctx context.Context, key string) *redis.IntCmd
+	Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+}
+
+var _ RedisClient = redis.UniversalClient(nil)
+
+// isCluster 判断是否连接的是 Redis Cluster
+func isCluster(rdb interface{}) bool {
+	_, ok := rdb.(*redis.ClusterClient)
+	return ok
+}
+
+// Client 创建分布式锁的客户端
+type Client struct {
+	rdb RedisClient
+}
+
+// NewClient 基于 Redis 连接创建锁客户端
+func NewClient(rdb RedisClient) *Client {
+	return &Client{rdb: rdb}
+}
+
+// NewLock 创建指定 key 的锁，此时并不会去获取锁
+func (c *Client) NewLock(key string, opts *Options) *RedisLock {
+	o := opts.withDefaults()
+	l := &RedisLock{
+		client:        c,
+		key:           key,
+		value:         o.Value,
+		ttl:           o.TTL,
+		retry:         o.Retry,
+		wakeOnRelease: o.WakeOnRelease,
+		obs:           newObservation(o, key),
+	}
+	l.watchdog = newWatchdog(o, l.Refresh, l.obs)
+	return l
+}
+
+// withDefaults 返回补齐默认值的锁配置副本：未指定 Value 时为每个实例生成随机的持有者标识
+func (o *Options) withDefaults() *Options {
+	var opts Options
+	if o != nil {
+		opts = *o
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultTTL
+	}
+	if opts.Value == "" {
+		opts.Value = randid.New()
+	}
+	if opts.Retry == nil {
+		opts.Retry = DefaultRetry
+	}
+	if opts.RenewInterval <= 0 {
+		opts.RenewInterval = opts.TTL / 3
+	}
+	if opts.Observer == nil {
+		opts.Observer = NopObserver{}
+	}
+	if opts.WaiterTimeout <= 0 {
+		opts.WaiterTimeout = DefaultWaiterTimeout
+	}
+	if opts.NodeTimeout <= 0 {
+		opts.NodeTimeout = max(opts.TTL/100, DefaultNodeTimeout)
+	}
+	return &opts
+}
+
+// RedisLock 基于 SETNX + Lua 脚本的 Redis 分布式锁
+type RedisLock struct {
+	client *Client
+	key    string
+	value  string
+	ttl    time.Duration
+	retry  RetryStrategy
+
+	wakeOnRelease bool
+	watchdog      *watchdog
+	obs           *observation
+
+	token int64 // 最近一次获取锁得到的 fencing token
+}
+
+var _ Locker = (*RedisLock)(nil)
+
+// Key 返回锁的 key
+func (l *RedisLock) Key() string {
+	return l.key
+}
+
+// Value 返回锁持有者标识
+func (l *RedisLock) Value() string {
+	return l.value
+}
+
+// Token 返回最近一次获取锁得到的 fencing token，尚未获取过锁时返回 0
+func (l *RedisLock) Token() int64 {
+	return atomic.LoadInt64(&l.token)
+}
+
+// Lock 阻塞获取锁，按重试策略重试直到获取成功或 ctx 结束
+func (l *RedisLock) Lock(ctx context.Context) error {
+	return l.obs.lock(ctx, func() error {
+		return l.lock(ctx)
+	})
+}
+
+func (l *RedisLock) lock(ctx context.Context) error {
+	var wake <-chan *redis.Message
+	if l.wakeOnRelease {
+		sub, ch, err := subscribeRelease(ctx, l.client.rdb, l.key)
+		if err != nil {
+			return err
+		}
+		defer sub.Close()
+		wake = ch
+	}
+	return retryLoop(ctx, l.retry, wake, func() (bool, error) {
+		return l.tryLock(ctx)
+	})
+}
+
+// TryLock 尝试获取一次锁，获取成功时同时分配新的 fencing token
+func (l *RedisLock) TryLock(ctx context.Context) (bool, error) {
+	return l.obs.tryLock(ctx, func() (bool, error) {
+		return l.tryLock(ctx)
+	})
+}
+
+func (l *RedisLock) tryLock(ctx context.Context) (bool, error) {
+	keys := []string{l.key, FenceKey(l.key)}
+	token, err := acquireScript.Run(ctx, l.client.rdb, keys, l.value, l.ttl.Milliseconds()).Int64()
+	if err != nil && ctx.Err() != nil {
+		// ctx 在脚本执行期间结束时无法确定是否已获取，尽力释放，避免锁残留到过期
+		releaseScript.Run(context.WithoutCancel(ctx), l.client.rdb, []string{l.key}, l.value, ReleaseChannel(l.key))
+	}
+	if err != nil || token == 0 {
+		return false, err
+	}
+	atomic.StoreInt64(&l.token, token)
+	l.watchdog.start(ctx)
+	return true, nil
+}
+
+// Unlock 释放锁
+func (l *RedisLock) Unlock(ctx context.Context) error {
+	l.watchdog.stop()
+
+	// 使用Lua脚本确保原子性：只有锁的持有者才能释放锁
+	res, err := releaseScript.Run(ctx, l.client.rdb, []string{l.key}, l.value, ReleaseChannel(l.key)).Int64()
+	if err == nil && res != 1 {
+		err = ErrLockNotHeld
+	}
+	return l.obs.unlock(ctx, err)
+}
+
+// Refresh 延长锁的过期时间
+func (l *RedisLock) Refresh(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = l.ttl
+	}
+	res, err := refreshScript.Run(ctx, l.client.rdb, []string{l.key}, l.value, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if res != 1 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// TTL 返回锁的剩余过期时间
+func (l *RedisLock) TTL(ctx context.Context) (time.Duration, error) {
+	res, err := pttlScript.Run(ctx, l.client.rdb, []string{l.key}, l.value).Int64()
+	if err != nil {
+		return 0, err
+	}
+	if res <= 0 {
+		return 0, nil
+	}
+	return time.Duration(res) * time.Millisecond, nil
+}
+
+// Lost 返回锁丢失信号：看门狗续期失败（锁已过期或被其他进程获取）时关闭。
+// 每次获取锁都会生成新的信号，未开启 AutoRenew 时永远不会关闭
+func (l *RedisLock) Lost() <-chan struct{} {
+	return l.watchdog.Lost()
+}
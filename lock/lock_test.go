@@ -0,0 +1,79 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"goRedisLock/internal/redistest"
+)
+
+// 创建测试用的锁客户端，并在测试结束时清理 key
+func newTestClient(t *testing.T, keys ...string) *Client {
+	t.Helper()
+	return NewClient(redistest.New(t, keys...).Client)
+}
+
+func TestRedisLockRefreshAndTTL(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, "test_refresh_lock")
+
+	locker := client.NewLock("test_refresh_lock", &Options{TTL: 2 * time.Second})
+	if err := locker.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+
+	// 其他持有者无法获取、续期或释放
+	other := client.NewLock("test_refresh_lock", nil)
+	if ok, err := other.TryLock(ctx); err != nil || ok {
+		t.Errorf("锁已被持有，其他持有者不应获取成功: %v, %v", ok, err)
+	}
+	if err := other.Refresh(ctx, time.Minute); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("期望 ErrLockNotHeld，实际: %v", err)
+	}
+	if ttl, err := other.TTL(ctx); err != nil || ttl != 0 {
+		t.Errorf("非持有者的 TTL 应为 0，实际: %v, %v", ttl, err)
+	}
+
+	if err := locker.Refresh(ctx, time.Minute); err != nil {
+		t.Fatalf("续期失败: %v", err)
+	}
+	ttl, err := locker.TTL(ctx)
+	if err != nil {
+		t.Fatalf("查询 TTL 失败: %v", err)
+	}
+	if ttl <= 2*time.Second || ttl > time.Minute {
+		t.Errorf("续期后 TTL 不正确: %v", ttl)
+	}
+
+	if err := locker.Unlock(ctx); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+	if err := locker.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("重复释放应返回 ErrLockNotHeld，实际: %v", err)
+	}
+}
+
+func TestRedisLockExpires(t *testing.T) {
+	ctx := context.Background()
+	server := redistest.New(t, "test_expire_lock")
+	client := NewClient(server.Client)
+
+	locker := client.NewLock("test_expire_lock", &Options{TTL: 2 * time.Second})
+	if err := locker.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+	server.FastForward(3 * time.Second)
+
+	if ttl, err := locker.TTL(ctx); err != nil || ttl != 0 {
+		t.Errorf("过期后 TTL 应为 0，实际: %v, %v", ttl, err)
+	}
+	if err := locker.Refresh(ctx, 0); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("过期后续期应返回 ErrLockNotHeld，实际: %v", err)
+	}
+	other := client.NewLock("test_expire_lock", &Options{Retry: NoRetry()})
+	if err := other.Lock(ctx); err != nil {
+		t.Errorf("锁过期后其他持有者应获取成功: %v", err)
+	}
+}
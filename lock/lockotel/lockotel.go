@@ -0,0 +1,70 @@
+// Package lockotel 将锁事件上报为 OpenTelemetry span
+package lockotel
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"goRedisLock/lock"
+)
+
+const instrumentationName = "goRedisLock/lock"
+
+// Observer 实现 lock.Observer：获取锁和持有锁分别记录为 lock.acquire、lock.hold 两个 span，
+// span 的开始时间按等待/持有时长回推；续期失败记录为当前 span 上的事件
+type Observer struct {
+	tracer trace.Tracer
+}
+
+var _ lock.Observer = (*Observer)(nil)
+
+// NewObserver 基于 TracerProvider 创建 Observer
+func NewObserver(tp trace.TracerProvider) *Observer {
+	return &Observer{tracer: tp.Tracer(instrumentationName)}
+}
+
+func (o *Observer) AcquireAttempted(context.Context, string) {}
+
+func (o *Observer) AcquireSucceeded(ctx context.Context, key string, wait time.Duration) {
+	o.record(ctx, "lock.acquire", key, wait, nil)
+}
+
+func (o *Observer) AcquireFailed(ctx context.Context, key string, wait time.Duration, err error) {
+	o.record(ctx, "lock.acquire", key, wait, err)
+}
+
+func (o *Observer) Released(ctx context.Context, key string, held time.Duration) {
+	o.record(ctx, "lock.hold", key, held, nil)
+}
+
+func (o *Observer) RenewalFailed(ctx context.Context, key string, err error) {
+	trace.SpanFromContext(ctx).AddEvent("lock.renewal_failed", trace.WithAttributes(
+		attribute.String("lock.key", key),
+		attribute.String("error", err.Error()),
+	))
+}
+
+func (o *Observer) ExpiredBeforeRelease(ctx context.Context, key string, held time.Duration) {
+	o.record(ctx, "lock.hold", key, held, lock.ErrLockNotHeld)
+}
+
+// record 记录一个已经结束的 span
+func (o *Observer) record(ctx context.Context, name, key string, d time.Duration, err error) {
+	end := time.Now()
+	_, span := o.tracer.Start(ctx, name,
+		trace.WithTimestamp(end.Add(-d)),
+		trace.WithAttributes(attribute.String("lock.key", key)),
+	)
+	if err != nil {
+		if !errors.Is(err, lock.ErrNotObtained) && !errors.Is(err, lock.ErrLockNotHeld) {
+			span.RecordError(err)
+		}
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End(trace.WithTimestamp(end))
+}
@@ -0,0 +1,41 @@
+package lockotel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"goRedisLock/lock"
+)
+
+func TestObserverSpans(t *testing.T) {
+	ctx := context.Background()
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	o := NewObserver(tp)
+
+	o.AcquireSucceeded(ctx, "mylock", 100*time.Millisecond)
+	o.AcquireFailed(ctx, "mylock", time.Second, lock.ErrNotObtained)
+	o.Released(ctx, "mylock", 2*time.Second)
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("期望 3 个 span，实际 %d 个", len(spans))
+	}
+	if spans[0].Name() != "lock.acquire" || spans[2].Name() != "lock.hold" {
+		t.Errorf("span 名称不正确: %s, %s", spans[0].Name(), spans[2].Name())
+	}
+	if d := spans[0].EndTime().Sub(spans[0].StartTime()); d != 100*time.Millisecond {
+		t.Errorf("获取锁 span 时长应为等待时间，实际 %v", d)
+	}
+	if spans[1].Status().Code != codes.Error {
+		t.Error("获取失败的 span 应标记为错误")
+	}
+	if d := spans[2].EndTime().Sub(spans[2].StartTime()); d != 2*time.Second {
+		t.Errorf("持有锁 span 时长应为持有时间，实际 %v", d)
+	}
+}
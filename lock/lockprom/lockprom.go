@@ -0,0 +1,127 @@
+// Package lockprom 将锁事件导出为 Prometheus 指标
+package lockprom
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"goRedisLock/lock"
+)
+
+// Collector 实现 lock.Observer 的 Prometheus 采集器，注册到 Registry 后即可导出指标。
+//
+// 所有指标都以锁 key 作为 "key" 标签，且不做基数限制：每个不同的 key 都会产生一组新的时间序列。
+// 只应用于数量有限的固定 key（如 "jobs:inactivity-check"），key 中带有用户ID、订单号等
+// 无界取值时不要挂载该采集器，否则 Prometheus 的内存和查询开销会随 key 数量无限增长
+type Collector struct {
+	attempts        *prometheus.CounterVec
+	acquired        *prometheus.CounterVec
+	failures        *prometheus.CounterVec
+	waitSeconds     *prometheus.HistogramVec
+	holdSeconds     *prometheus.HistogramVec
+	renewalFailures *prometheus.CounterVec
+	expired         *prometheus.CounterVec
+}
+
+var (
+	_ lock.Observer        = (*Collector)(nil)
+	_ prometheus.Collector = (*Collector)(nil)
+)
+
+// NewCollector 创建采集器，namespace 为指标名前缀，可为空
+func NewCollector(namespace string) *Collector {
+	return &Collector{
+		attempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "lock_acquire_attempts_total",
+			Help:      "获取锁的尝试次数",
+		}, []string{"key"}),
+		acquired: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "lock_acquired_total",
+			Help:      "获取锁成功的次数",
+		}, []string{"key"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "lock_acquire_failures_total",
+			Help:      "获取锁失败的次数，reason 为 not_obtained 或 error",
+		}, []string{"key", "reason"}),
+		waitSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "lock_wait_seconds",
+			Help:      "获取锁的等待时间",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+		}, []string{"key", "result"}),
+		holdSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "lock_hold_seconds",
+			Help:      "锁的持有时长",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 4, 10),
+		}, []string{"key"}),
+		renewalFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "lock_renewal_failures_total",
+			Help:      "看门狗续期失败的次数",
+		}, []string{"key"}),
+		expired: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "lock_expired_before_release_total",
+			Help:      "释放前锁已过期或被其他持有者获取的次数",
+		}, []string{"key"}),
+	}
+}
+
+func (c *Collector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.attempts, c.acquired, c.failures, c.waitSeconds,
+		c.holdSeconds, c.renewalFailures, c.expired,
+	}
+}
+
+// Describe 实现 prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, col := range c.collectors() {
+		col.Describe(ch)
+	}
+}
+
+// Collect 实现 prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, col := range c.collectors() {
+		col.Collect(ch)
+	}
+}
+
+func (c *Collector) AcquireAttempted(_ context.Context, key string) {
+	c.attempts.WithLabelValues(key).Inc()
+}
+
+func (c *Collector) AcquireSucceeded(_ context.Context, key string, wait time.Duration) {
+	c.acquired.WithLabelValues(key).Inc()
+	c.waitSeconds.WithLabelValues(key, "success").Observe(wait.Seconds())
+}
+
+func (c *Collector) AcquireFailed(_ context.Context, key string, wait time.Duration, err error) {
+	reason := "error"
+	if errors.Is(err, lock.ErrNotObtained) {
+		reason = "not_obtained"
+	}
+	c.failures.WithLabelValues(key, reason).Inc()
+	c.waitSeconds.WithLabelValues(key, "failure").Observe(wait.Seconds())
+}
+
+func (c *Collector) Released(_ context.Context, key string, held time.Duration) {
+	c.holdSeconds.WithLabelValues(key).Observe(held.Seconds())
+}
+
+func (c *Collector) RenewalFailed(_ context.Context, key string, _ error) {
+	c.renewalFailures.WithLabelValues(key).Inc()
+}
+
+func (c *Collector) ExpiredBeforeRelease(_ context.Context, key string, held time.Duration) {
+	c.expired.WithLabelValues(key).Inc()
+	c.holdSeconds.WithLabelValues(key).Observe(held.Seconds())
+}
@@ -0,0 +1,57 @@
+package lockprom
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"goRedisLock/lock"
+)
+
+func TestCollector(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollector("test")
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(c); err != nil {
+		t.Fatalf("注册采集器失败: %v", err)
+	}
+
+	c.AcquireAttempted(ctx, "mylock")
+	c.AcquireSucceeded(ctx, "mylock", 10*time.Millisecond)
+	c.AcquireAttempted(ctx, "mylock")
+	c.AcquireFailed(ctx, "mylock", time.Second, lock.ErrNotObtained)
+	c.AcquireAttempted(ctx, "mylock")
+	c.AcquireFailed(ctx, "mylock", time.Millisecond, errors.New("connection refused"))
+	c.Released(ctx, "mylock", 2*time.Second)
+	c.RenewalFailed(ctx, "mylock", lock.ErrLockNotHeld)
+	c.ExpiredBeforeRelease(ctx, "mylock", 30*time.Second)
+
+	if v := testutil.ToFloat64(c.attempts.WithLabelValues("mylock")); v != 3 {
+		t.Errorf("尝试次数期望 3，实际 %v", v)
+	}
+	if v := testutil.ToFloat64(c.acquired.WithLabelValues("mylock")); v != 1 {
+		t.Errorf("成功次数期望 1，实际 %v", v)
+	}
+	if v := testutil.ToFloat64(c.failures.WithLabelValues("mylock", "not_obtained")); v != 1 {
+		t.Errorf("锁被占用的失败次数期望 1，实际 %v", v)
+	}
+	if v := testutil.ToFloat64(c.failures.WithLabelValues("mylock", "error")); v != 1 {
+		t.Errorf("出错的失败次数期望 1，实际 %v", v)
+	}
+	if v := testutil.ToFloat64(c.renewalFailures.WithLabelValues("mylock")); v != 1 {
+		t.Errorf("续期失败次数期望 1，实际 %v", v)
+	}
+	if v := testutil.ToFloat64(c.expired.WithLabelValues("mylock")); v != 1 {
+		t.Errorf("过期次数期望 1，实际 %v", v)
+	}
+	if n := testutil.CollectAndCount(c, "test_lock_hold_seconds"); n != 1 {
+		t.Errorf("持有时长指标期望 1 条序列，实际 %d", n)
+	}
+	if _, err := reg.Gather(); err != nil {
+		t.Errorf("采集指标失败: %v", err)
+	}
+}
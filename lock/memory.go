@@ -0,0 +1,183 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MemoryStore 进程内的锁存储，用于单机部署和测试。
+// 同一个 MemoryStore 创建的锁之间互斥，不同进程之间不互斥
+type MemoryStore struct {
+	mu    sync.Mutex
+	locks map[string]memoryEntry
+	fence map[string]int64 // 各 key 的 fencing token 计数器，释放后保留
+
+	now func() time.Time
+}
+
+type memoryEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+// NewMemoryStore 创建进程内的锁存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		locks: make(map[string]memoryEntry),
+		fence: make(map[string]int64),
+		now:   time.Now,
+	}
+}
+
+// NewLock 创建指定 key 的锁，此时并不会去获取锁。Options.WakeOnRelease 不生效
+func (s *MemoryStore) NewLock(key string, opts *Options) *MemoryLock {
+	o := opts.withDefaults()
+	l := &MemoryLock{
+		store: s,
+		key:   key,
+		value: o.Value,
+		ttl:   o.TTL,
+		retry: o.Retry,
+		obs:   newObservation(o, key),
+	}
+	l.watchdog = newWatchdog(o, l.Refresh, l.obs)
+	return l
+}
+
+// holder 返回 key 当前未过期的持有者，调用方需持有 s.mu
+func (s *MemoryStore) holder(key string) (memoryEntry, bool) {
+	e, ok := s.locks[key]
+	if !ok {
+		return e, false
+	}
+	if !s.now().Before(e.expiresAt) {
+		delete(s.locks, key)
+		return e, false
+	}
+	return e, true
+}
+
+// MemoryLock 进程内的锁，语义与 RedisLock 相同
+type MemoryLock struct {
+	store *MemoryStore
+	key   string
+	value string
+	ttl   time.Duration
+	retry RetryStrategy
+
+	watchdog *watchdog
+	obs      *observation
+	token    int64
+}
+
+var _ Locker = (*MemoryLock)(nil)
+
+// Key 返回锁的 key
+func (l *MemoryLock) Key() string {
+	return l.key
+}
+
+// Value 返回锁持有者标识
+func (l *MemoryLock) Value() string {
+	return l.value
+}
+
+// Token 返回最近一次获取锁得到的 fencing token，尚未获取过锁时返回 0
+func (l *MemoryLock) Token() int64 {
+	return atomic.LoadInt64(&l.token)
+}
+
+// Lock 阻塞获取锁，按重试策略重试直到获取成功或 ctx 结束
+func (l *MemoryLock) Lock(ctx context.Context) error {
+	return l.obs.lock(ctx, func() error {
+		return l.lock(ctx)
+	})
+}
+
+func (l *MemoryLock) lock(ctx context.Context) error {
+	return retryLoop(ctx, l.retry, nil, func() (bool, error) {
+		return l.tryLock(ctx)
+	})
+}
+
+// TryLock 尝试获取一次锁，获取成功时同时分配新的 fencing token
+func (l *MemoryLock) TryLock(ctx context.Context) (bool, error) {
+	return l.obs.tryLock(ctx, func() (bool, error) {
+		return l.tryLock(ctx)
+	})
+}
+
+func (l *MemoryLock) tryLock(ctx context.Context) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	s := l.store
+	s.mu.Lock()
+	if _, held := s.holder(l.key); held {
+		s.mu.Unlock()
+		return false, nil
+	}
+	s.locks[l.key] = memoryEntry{value: l.value, expiresAt: s.now().Add(l.ttl)}
+	s.fence[l.key]++
+	atomic.StoreInt64(&l.token, s.fence[l.key])
+	s.mu.Unlock()
+
+	l.watchdog.start(ctx)
+	return true, nil
+}
+
+// Unlock 释放锁
+func (l *MemoryLock) Unlock(ctx context.Context) error {
+	return l.obs.unlock(ctx, l.unlock(ctx))
+}
+
+func (l *MemoryLock) unlock(ctx context.Context) error {
+	l.watchdog.stop()
+
+	s := l.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, held := s.holder(l.key); !held || e.value != l.value {
+		return ErrLockNotHeld
+	}
+	delete(s.locks, l.key)
+	return nil
+}
+
+// Refresh 延长锁的过期时间
+func (l *MemoryLock) Refresh(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = l.ttl
+	}
+
+	s := l.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, held := s.holder(l.key)
+	if !held || e.value != l.value {
+		return ErrLockNotHeld
+	}
+	e.expiresAt = s.now().Add(ttl)
+	s.locks[l.key] = e
+	return nil
+}
+
+// TTL 返回锁的剩余过期时间
+func (l *MemoryLock) TTL(ctx context.Context) (time.Duration, error) {
+	s := l.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, held := s.holder(l.key)
+	if !held || e.value != l.value {
+		return 0, nil
+	}
+	return e.expiresAt.Sub(s.now()), nil
+}
+
+// Lost 返回锁丢失信号：看门狗续期失败时关闭，未开启 AutoRenew 时永远不会关闭
+func (l *MemoryLock) Lost() <-chan struct{} {
+	return l.watchdog.Lost()
+}
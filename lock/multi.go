@@ -0,0 +1,205 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	// ErrCrossSlot Redis Cluster 中多 key 锁的 key 不在同一个 hash slot，无法在一个脚本中原子获取
+	ErrCrossSlot = errors.New("lock: 多个 key 不在同一个 hash slot")
+	// ErrNoKeys 多 key 锁没有指定任何 key
+	ErrNoKeys = errors.New("lock: 未指定要锁定的 key")
+)
+
+// MultiLock 同时锁定多个 key 的锁：在一个 Lua 脚本中原子地获取全部 key，
+// 要么全部获取成功，要么一个都不获取，不会出现部分获取和交叉死锁。
+// 每个 key 的存储方式与 RedisLock 相同，因此与单 key 的锁互斥。
+// 在 Redis Cluster 中全部 key 必须使用相同的 hash tag，如 {order:42}:stock 和 {order:42}:payment
+type MultiLock struct {
+	client *Client
+	keys   []string
+	value  string
+	ttl    time.Duration
+	retry  RetryStrategy
+
+	wakeOnRelease bool
+	watchdog      *watchdog
+	obs           *observation
+}
+
+var _ Locker = (*MultiLock)(nil)
+
+// NewMultiLock 创建锁定 keys 的锁，keys 会被去重并排序，此时并不会去获取锁。
+// keys 为空时获取锁返回 ErrNoKeys
+func (c *Client) NewMultiLock(keys []string, opts *Options) *MultiLock {
+	o := opts.withDefaults()
+	sorted := sortedKeys(keys)
+	m := &MultiLock{
+		client:        c,
+		keys:          sorted,
+		value:         o.Value,
+		ttl:           o.TTL,
+		retry:         o.Retry,
+		wakeOnRelease: o.WakeOnRelease,
+		obs:           newObservation(o, strings.Join(sorted, ",")),
+	}
+	m.watchdog = newWatchdog(o, m.Refresh, m.obs)
+	return m
+}
+
+// LockMulti 使用默认配置阻塞获取 keys 上的锁
+func (c *Client) LockMulti(ctx context.Context, keys ...string) (*MultiLock, error) {
+	m := c.NewMultiLock(keys, nil)
+	if err := m.Lock(ctx); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// UnlockMulti 释放 LockMulti 获取的锁
+func (c *Client) UnlockMulti(ctx context.Context, m *MultiLock) error {
+	return m.Unlock(ctx)
+}
+
+// Keys 返回排序后的 key 列表
+func (m *MultiLock) Keys() []string {
+	return append([]string(nil), m.keys...)
+}
+
+// Value 返回锁持有者标识
+func (m *MultiLock) Value() string {
+	return m.value
+}
+
+// Lock 阻塞获取全部 key，按重试策略重试直到获取成功或 ctx 结束
+func (m *MultiLock) Lock(ctx context.Context) error {
+	return m.obs.lock(ctx, func() error {
+		return m.lock(ctx)
+	})
+}
+
+func (m *MultiLock) lock(ctx context.Context) error {
+	if len(m.keys) == 0 {
+		return ErrNoKeys
+	}
+	var wake <-chan *redis.Message
+	if m.wakeOnRelease {
+		sub := m.client.rdb.Subscribe(ctx, m.channels()...)
+		defer sub.Close()
+		// 等待全部频道的订阅确认
+		for range m.keys {
+			if _, err := sub.Receive(ctx); err != nil {
+				return err
+			}
+		}
+		wake = sub.Channel()
+	}
+	return retryLoop(ctx, m.retry, wake, func() (bool, error) {
+		return m.tryLock(ctx)
+	})
+}
+
+// TryLock 尝试一次原子地获取全部 key，没有 key 时返回 ErrNoKeys
+func (m *MultiLock) TryLock(ctx context.Context) (bool, error) {
+	return m.obs.tryLock(ctx, func() (bool, error) {
+		return m.tryLock(ctx)
+	})
+}
+
+func (m *MultiLock) tryLock(ctx context.Context) (bool, error) {
+	if len(m.keys) == 0 {
+		return false, ErrNoKeys
+	}
+	if isCluster(m.client.rdb) && !sameSlot(m.keys) {
+		return false, ErrCrossSlot
+	}
+	res, err := multiAcquireScript.Run(ctx, m.client.rdb, m.keys, m.value, m.ttl.Milliseconds()).Int64()
+	if err != nil || res != 1 {
+		return false, err
+	}
+	m.watchdog.start(ctx)
+	return true, nil
+}
+
+// Unlock 释放全部 key，有 key 已过期或被其他持有者获取时返回 ErrLockNotHeld（其余 key 仍会被释放）
+func (m *MultiLock) Unlock(ctx context.Context) error {
+	return m.obs.unlock(ctx, m.unlock(ctx))
+}
+
+func (m *MultiLock) unlock(ctx context.Context) error {
+	m.watchdog.stop()
+
+	args := []interface{}{m.value}
+	for _, ch := range m.channels() {
+		args = append(args, ch)
+	}
+	released, err := multiReleaseScript.Run(ctx, m.client.rdb, m.keys, args...).Int64()
+	if err != nil {
+		return err
+	}
+	if released != int64(len(m.keys)) {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// Refresh 延长全部 key 的过期时间，有 key 不再持有时返回 ErrLockNotHeld
+func (m *MultiLock) Refresh(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = m.ttl
+	}
+	res, err := multiRefreshScript.Run(ctx, m.client.rdb, m.keys, m.value, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if res != 1 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// TTL 返回全部 key 中最小的剩余过期时间
+func (m *MultiLock) TTL(ctx context.Context) (time.Duration, error) {
+	res, err := multiPTTLScript.Run(ctx, m.client.rdb, m.keys, m.value).Int64()
+	if err != nil {
+		return 0, err
+	}
+	if res <= 0 {
+		return 0, nil
+	}
+	return time.Duration(res) * time.Millisecond, nil
+}
+
+// Lost 返回锁丢失信号：看门狗续期失败时关闭，未开启 AutoRenew 时永远不会关闭
+func (m *MultiLock) Lost() <-chan struct{} {
+	return m.watchdog.Lost()
+}
+
+// channels 返回各个 key 的锁释放通知频道
+func (m *MultiLock) channels() []string {
+	channels := make([]string, len(m.keys))
+	for i, k := range m.keys {
+		channels[i] = ReleaseChannel(k)
+	}
+	return channels
+}
+
+// sortedKeys 返回去重并排序后的 key 列表
+func sortedKeys(keys []string) []string {
+	seen := make(map[string]bool, len(keys))
+	sorted := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if !seen[k] {
+			seen[k] = true
+			sorted = append(sorted, k)
+		}
+	}
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,105 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"goRedisLock/internal/redistest"
+)
+
+func TestMultiLockAllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	server := redistest.New(t, "post:1", "section:2", "section:3")
+	client := NewClient(server.Client)
+
+	// 版块已被单 key 锁占用
+	section := client.NewLock("section:2", &Options{TTL: 5 * time.Second})
+	if err := section.Lock(ctx); err != nil {
+		t.Fatalf("获取版块锁失败: %v", err)
+	}
+
+	m := client.NewMultiLock([]string{"section:2", "post:1", "post:1"}, &Options{TTL: 5 * time.Second, Retry: NoRetry()})
+	if keys := m.Keys(); len(keys) != 2 || keys[0] != "post:1" || keys[1] != "section:2" {
+		t.Errorf("key 应去重并排序，实际: %v", keys)
+	}
+	if err := m.Lock(ctx); !errors.Is(err, ErrNotObtained) {
+		t.Fatalf("部分 key 被占用时应获取失败，实际: %v", err)
+	}
+	if server.Client.Exists(ctx, "post:1").Val() != 0 {
+		t.Error("获取失败时不应留下部分获取的 key")
+	}
+
+	if err := section.Unlock(ctx); err != nil {
+		t.Fatalf("释放版块锁失败: %v", err)
+	}
+	if err := m.Lock(ctx); err != nil {
+		t.Fatalf("全部 key 空闲时应获取成功: %v", err)
+	}
+	if ok, _ := section.TryLock(ctx); ok {
+		t.Error("多 key 锁持有期间单 key 锁不应获取成功")
+	}
+	if ttl, err := m.TTL(ctx); err != nil || ttl <= 0 {
+		t.Errorf("TTL 不正确: %v, %v", ttl, err)
+	}
+	if err := m.Refresh(ctx, time.Minute); err != nil {
+		t.Errorf("续期失败: %v", err)
+	}
+	if err := client.UnlockMulti(ctx, m); err != nil {
+		t.Fatalf("释放失败: %v", err)
+	}
+	if err := m.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("重复释放应返回 ErrLockNotHeld，实际: %v", err)
+	}
+}
+
+func TestMultiLockNoDeadlock(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, "post:1", "section:2")
+
+	// 两组调用方以相反顺序锁定同样的资源，不会互相死锁
+	var wg sync.WaitGroup
+	for i, keys := range [][]string{{"post:1", "section:2"}, {"section:2", "post:1"}} {
+		wg.Add(1)
+		go func(id int, keys []string) {
+			defer wg.Done()
+			for n := 0; n < 5; n++ {
+				waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+				m, err := client.LockMulti(waitCtx, keys...)
+				cancel()
+				if err != nil {
+					t.Errorf("调用方%d 获取锁失败: %v", id, err)
+					return
+				}
+				time.Sleep(5 * time.Millisecond)
+				if err := client.UnlockMulti(ctx, m); err != nil {
+					t.Errorf("调用方%d 释放锁失败: %v", id, err)
+					return
+				}
+			}
+		}(i, keys)
+	}
+	wg.Wait()
+}
+
+func TestMultiLockNoKeys(t *testing.T) {
+	ctx := context.Background()
+	clients := map[string]*Client{
+		"standalone": NewClient(redistest.New(t).Client),
+		"cluster":    NewClient(redistest.NewCluster(t).Cluster),
+	}
+	for name, client := range clients {
+		m := client.NewMultiLock(nil, &Options{WakeOnRelease: true})
+		if ok, err := m.TryLock(ctx); ok || !errors.Is(err, ErrNoKeys) {
+			t.Errorf("%s: TryLock 期望 ErrNoKeys，实际: %v, %v", name, ok, err)
+		}
+		if err := m.Lock(ctx); !errors.Is(err, ErrNoKeys) {
+			t.Errorf("%s: Lock 期望 ErrNoKeys，实际: %v", name, err)
+		}
+		if _, err := client.LockMulti(ctx); !errors.Is(err, ErrNoKeys) {
+			t.Errorf("%s: LockMulti 期望 ErrNoKeys，实际: %v", name, err)
+		}
+	}
+}
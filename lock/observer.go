@@ -0,0 +1,105 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// Observer 锁事件观察者，用于采集指标和链路追踪，实现需要支持并发调用。
+// Lock/TryLock 每次调用记一次获取尝试，wait 为本次调用的总等待时间；
+// 每次成功获取最多上报一次 Released 或 ExpiredBeforeRelease。
+// 所有锁类型都会上报：可重入锁从第一次获取到完全释放算一次持有，读写锁的读锁和写锁分别统计，
+// MultiLock 的 key 为以逗号连接的全部 key
+type Observer interface {
+	// AcquireAttempted 开始获取锁
+	AcquireAttempted(ctx context.Context, key string)
+	// AcquireSucceeded 获取锁成功
+	AcquireSucceeded(ctx context.Context, key string, wait time.Duration)
+	// AcquireFailed 获取锁失败，err 为 ErrNotObtained 或 Redis 错误
+	AcquireFailed(ctx context.Context, key string, wait time.Duration, err error)
+	// Released 持有者主动释放锁，held 为持有时长
+	Released(ctx context.Context, key string, held time.Duration)
+	// RenewalFailed 看门狗续期失败
+	RenewalFailed(ctx context.Context, key string, err error)
+	// ExpiredBeforeRelease 释放时发现锁已过期或被其他持有者获取
+	ExpiredBeforeRelease(ctx context.Context, key string, held time.Duration)
+}
+
+// NopObserver 忽略所有事件，可嵌入到只关心部分事件的 Observer 实现中
+type NopObserver struct{}
+
+var _ Observer = NopObserver{}
+
+func (NopObserver) AcquireAttempted(context.Context, string)                    {}
+func (NopObserver) AcquireSucceeded(context.Context, string, time.Duration)     {}
+func (NopObserver) AcquireFailed(context.Context, string, time.Duration, error) {}
+func (NopObserver) Released(context.Context, string, time.Duration)             {}
+func (NopObserver) RenewalFailed(context.Context, string, error)                {}
+func (NopObserver) ExpiredBeforeRelease(context.Context, string, time.Duration) {}
+
+// observation 各类锁共用的事件上报，统计获取的等待时间和持有时长
+type observation struct {
+	observer   Observer
+	key        string
+	acquiredAt int64 // 当前这次持有的获取时间（UnixNano），释放后清零
+}
+
+func newObservation(opts *Options, key string) *observation {
+	return &observation{observer: opts.Observer, key: key}
+}
+
+// tryLock 执行一次获取并上报结果，try 返回 false 且没有错误时按 ErrNotObtained 上报
+func (o *observation) tryLock(ctx context.Context, try func() (bool, error)) (bool, error) {
+	start := time.Now()
+	o.observer.AcquireAttempted(ctx, o.key)
+
+	ok, err := try()
+	failure := err
+	if failure == nil && !ok {
+		failure = ErrNotObtained
+	}
+	if failure != nil {
+		o.observer.AcquireFailed(ctx, o.key, time.Since(start), failure)
+		return ok, err
+	}
+	now := time.Now()
+	// 已持有时再次获取（可重入锁、公平锁的重复 TryLock）保留最初的获取时间
+	atomic.CompareAndSwapInt64(&o.acquiredAt, 0, now.UnixNano())
+	o.observer.AcquireSucceeded(ctx, o.key, now.Sub(start))
+	return true, nil
+}
+
+// lock 执行阻塞获取并上报结果，wait 为整个等待过程的耗时
+func (o *observation) lock(ctx context.Context, lock func() error) error {
+	_, err := o.tryLock(ctx, func() (bool, error) {
+		err := lock()
+		return err == nil, err
+	})
+	return err
+}
+
+// unlock 上报释放结果并原样返回 err：成功时上报 Released，ErrLockNotHeld 时上报 ExpiredBeforeRelease；
+// 其他错误（如网络错误）时锁可能仍被持有，不上报。重复释放或从未获取过锁时不再上报
+func (o *observation) unlock(ctx context.Context, err error) error {
+	if err != nil && !errors.Is(err, ErrLockNotHeld) {
+		return err
+	}
+	acquiredAt := atomic.SwapInt64(&o.acquiredAt, 0)
+	if acquiredAt == 0 {
+		return err
+	}
+	held := time.Since(time.Unix(0, acquiredAt))
+	if err != nil {
+		o.observer.ExpiredBeforeRelease(ctx, o.key, held)
+	} else {
+		o.observer.Released(ctx, o.key, held)
+	}
+	return err
+}
+
+// renewalFailed 上报看门狗续期失败
+func (o *observation) renewalFailed(ctx context.Context, err error) {
+	o.observer.RenewalFailed(ctx, o.key, err)
+}
@@ -0,0 +1,211 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"goRedisLock/internal/redistest"
+)
+
+// recordingObserver 按顺序记录收到的事件
+type recordingObserver struct {
+	NopObserver
+
+	mu     sync.Mutex
+	events []string
+}
+
+func (o *recordingObserver) record(event string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, event)
+}
+
+func (o *recordingObserver) AcquireAttempted(context.Context, string) {
+	o.record("attempted")
+}
+
+func (o *recordingObserver) AcquireSucceeded(context.Context, string, time.Duration) {
+	o.record("succeeded")
+}
+
+func (o *recordingObserver) AcquireFailed(_ context.Context, _ string, _ time.Duration, err error) {
+	if errors.Is(err, ErrNotObtained) {
+		o.record("not_obtained")
+		return
+	}
+	o.record("failed")
+}
+
+func (o *recordingObserver) Released(context.Context, string, time.Duration) {
+	o.record("released")
+}
+
+func (o *recordingObserver) ExpiredBeforeRelease(context.Context, string, time.Duration) {
+	o.record("expired")
+}
+
+func (o *recordingObserver) Events() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]string(nil), o.events...)
+}
+
+func TestObserverEvents(t *testing.T) {
+	ctx := context.Background()
+	server := redistest.New(t, "test_observer_lock", FenceKey("test_observer_lock"))
+	client := NewClient(server.Client)
+
+	obs := &recordingObserver{}
+	locker := client.NewLock("test_observer_lock", &Options{TTL: 2 * time.Second, Observer: obs})
+	other := client.NewLock("test_observer_lock", &Options{Retry: NoRetry(), Observer: obs})
+
+	if err := locker.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+	if ok, _ := other.TryLock(ctx); ok {
+		t.Fatal("锁已被持有，不应获取成功")
+	}
+	if err := locker.Unlock(ctx); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+
+	// 锁在释放前过期
+	if err := locker.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+	server.FastForward(3 * time.Second)
+	if err := locker.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("期望 ErrLockNotHeld，实际: %v", err)
+	}
+
+	// 重复释放和释放从未获取过的锁不再上报事件
+	if err := locker.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("期望 ErrLockNotHeld，实际: %v", err)
+	}
+	never := client.NewLock("test_observer_lock", &Options{Observer: obs})
+	if err := never.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("期望 ErrLockNotHeld，实际: %v", err)
+	}
+
+	want := []string{
+		"attempted", "succeeded",
+		"attempted", "not_obtained",
+		"released",
+		"attempted", "succeeded",
+		"expired",
+	}
+	got := obs.Events()
+	if len(got) != len(want) {
+		t.Fatalf("事件不符合预期:\n期望 %v\n实际 %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("事件不符合预期:\n期望 %v\n实际 %v", want, got)
+		}
+	}
+}
+
+// observedCase 一种锁的获取、另一个持有者的尝试获取和释放
+type observedCase struct {
+	lock   func(ctx context.Context) error
+	try    func(ctx context.Context) (bool, error)
+	unlock func(ctx context.Context) error
+}
+
+// 各类锁都按相同的顺序上报事件
+func TestObserverAllLockTypes(t *testing.T) {
+	const key = "test_observer_all_lock"
+	server := redistest.New(t, key, key+":b", FenceKey(key), ReadersKey(key), WriterWaitingKey(key),
+		QueueKey(key), QueueTimeoutKey(key))
+	client := NewClient(server.Client)
+	memory := NewMemoryStore()
+	_, nodes := newRedlockNodes(t, 3)
+
+	cases := map[string]func(opts *Options) observedCase{
+		"fair": func(opts *Options) observedCase {
+			l, other := client.NewFairLock(key, opts), client.NewFairLock(key, opts)
+			return observedCase{l.Lock, other.TryLock, l.Unlock}
+		},
+		"reentrant": func(opts *Options) observedCase {
+			l, other := client.NewReentrantLock(key, opts), client.NewReentrantLock(key, opts)
+			return observedCase{l.Lock, other.TryLock, l.Unlock}
+		},
+		"rwlock_read": func(opts *Options) observedCase {
+			l, other := client.NewRWLock(key, opts), client.NewRWLock(key, opts)
+			return observedCase{l.RLock, other.TryLock, l.RUnlock}
+		},
+		"rwlock_write": func(opts *Options) observedCase {
+			l, other := client.NewRWLock(key, opts), client.NewRWLock(key, opts)
+			return observedCase{l.Lock, other.TryRLock, l.Unlock}
+		},
+		"semaphore": func(opts *Options) observedCase {
+			s, other := client.NewSemaphore(key, 1, opts), client.NewSemaphore(key, 1, opts)
+			return observedCase{s.Acquire, other.TryAcquire, s.Release}
+		},
+		"multi": func(opts *Options) observedCase {
+			m, other := client.NewMultiLock([]string{key, key + ":b"}, opts), client.NewMultiLock([]string{key + ":b"}, opts)
+			return observedCase{m.Lock, other.TryLock, m.Unlock}
+		},
+		"redlock": func(opts *Options) observedCase {
+			l, other := NewRedlock(nodes, key, opts), NewRedlock(nodes, key, opts)
+			return observedCase{l.Lock, other.TryLock, l.Unlock}
+		},
+		"memory": func(opts *Options) observedCase {
+			l, other := memory.NewLock(key, opts), memory.NewLock(key, opts)
+			return observedCase{l.Lock, other.TryLock, l.Unlock}
+		},
+	}
+	want := []string{"attempted", "succeeded", "attempted", "not_obtained", "released"}
+	for name, newCase := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			obs := &recordingObserver{}
+			c := newCase(&Options{TTL: 5 * time.Second, Retry: NoRetry(), Observer: obs})
+
+			if err := c.lock(ctx); err != nil {
+				t.Fatalf("获取锁失败: %v", err)
+			}
+			if ok, _ := c.try(ctx); ok {
+				t.Fatal("锁已被持有，不应获取成功")
+			}
+			if err := c.unlock(ctx); err != nil {
+				t.Fatalf("释放锁失败: %v", err)
+			}
+			// 重复释放不再上报
+			c.unlock(ctx)
+
+			if got := obs.Events(); !slices.Equal(got, want) {
+				t.Errorf("事件不符合预期:\n期望 %v\n实际 %v", want, got)
+			}
+		})
+	}
+}
+
+// 可重入锁从第一次获取到持有次数减到 0 只上报一次释放
+func TestObserverReentrantHold(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, "test_observer_reentrant_lock")
+
+	obs := &recordingObserver{}
+	l := client.NewReentrantLock("test_observer_reentrant_lock", &Options{Observer: obs})
+	for i := 0; i < 2; i++ {
+		if err := l.Lock(ctx); err != nil {
+			t.Fatalf("获取锁失败: %v", err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if err := l.Unlock(ctx); err != nil {
+			t.Fatalf("释放锁失败: %v", err)
+		}
+	}
+
+	want := []string{"attempted", "succeeded", "attempted", "succeeded", "released"}
+	if got := obs.Events(); !slices.Equal(got, want) {
+		t.Errorf("事件不符合预期:\n期望 %v\n实际 %v", want, got)
+	}
+}
@@ -0,0 +1,17 @@
+package lock
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// subscribeRelease 订阅锁释放通知，返回前确认订阅已生效，避免漏掉订阅前发布的通知
+func subscribeRelease(ctx context.Context, rdb RedisClient, key string) (*redis.PubSub, <-chan *redis.Message, error) {
+	sub := rdb.Subscribe(ctx, ReleaseChannel(key))
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, nil, err
+	}
+	return sub, sub.Channel(), nil
+}
@@ -0,0 +1,102 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWakeOnRelease(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, "test_wake_lock")
+
+	holder := client.NewLock("test_wake_lock", &Options{TTL: 10 * time.Second})
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		time.Sleep(200 * time.Millisecond)
+		if err := holder.Unlock(ctx); err != nil {
+			t.Errorf("释放锁失败: %v", err)
+		}
+	}()
+
+	// 兜底轮询间隔远大于持有时间，只有收到释放通知才能及时获取锁
+	waiter := client.NewLock("test_wake_lock", &Options{
+		TTL:           10 * time.Second,
+		Retry:         FixedBackoff(5 * time.Second),
+		WakeOnRelease: true,
+	})
+	waitCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := waiter.Lock(waitCtx); err != nil {
+		t.Fatalf("收到释放通知后未能获取锁: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("获取锁耗时过长，释放通知可能未生效: %v", elapsed)
+	}
+	wg.Wait()
+
+	if err := waiter.Unlock(ctx); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+}
+
+func TestWakeOnReleaseContention(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, "test_wake_race_lock")
+
+	numGoroutines := 20
+	var (
+		mu      sync.Mutex
+		holders int
+		maxSeen int
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < numGoroutines; i++ {
+		wg.Add(1)
+		go func(goroutineID int) {
+			defer wg.Done()
+
+			locker := client.NewLock("test_wake_race_lock", &Options{
+				TTL:           5 * time.Second,
+				Retry:         FixedBackoff(time.Second),
+				WakeOnRelease: true,
+			})
+			waitCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			defer cancel()
+			if err := locker.Lock(waitCtx); err != nil {
+				t.Errorf("Goroutine %d 获取锁失败: %v", goroutineID, err)
+				return
+			}
+
+			mu.Lock()
+			holders++
+			if holders > maxSeen {
+				maxSeen = holders
+			}
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			holders--
+			mu.Unlock()
+
+			if err := locker.Unlock(ctx); err != nil {
+				t.Errorf("Goroutine %d 释放锁失败: %v", goroutineID, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if maxSeen != 1 {
+		t.Errorf("同一时刻最多只能有1个持有者，实际有%d个", maxSeen)
+	}
+}
@@ -0,0 +1,199 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redlockDriftFactor 时钟漂移系数，参考 Redlock 算法取 TTL 的 1%
+const redlockDriftFactor = 0.01
+
+// Redlock 跨多个独立 Redis 节点的 Redlock 算法实现。
+// 在多数节点上获取成功，并且扣除耗时和时钟漂移后仍有剩余有效期时才算获取成功；
+// 释放时在所有节点上释放。开启 AutoRenew 时看门狗在所有节点上续期，
+// 续期未达到多数派时发出锁丢失信号
+type Redlock struct {
+	clients     []redis.UniversalClient
+	key         string
+	value       string
+	ttl         time.Duration
+	nodeTimeout time.Duration
+	retry       RetryStrategy
+	watchdog    *watchdog
+	obs         *observation
+
+	mu    sync.Mutex
+	until time.Time // 锁的有效期截止时间
+}
+
+var _ Locker = (*Redlock)(nil)
+
+// NewRedlock 基于多个独立 Redis 节点创建指定 key 的锁，此时并不会去获取锁
+func NewRedlock(clients []redis.UniversalClient, key string, opts *Options) *Redlock {
+	o := opts.withDefaults()
+	l := &Redlock{
+		clients:     clients,
+		key:         key,
+		value:       o.Value,
+		ttl:         o.TTL,
+		nodeTimeout: o.NodeTimeout,
+		retry:       o.Retry,
+		obs:         newObservation(o, key),
+	}
+	l.watchdog = newWatchdog(o, l.Refresh, l.obs)
+	return l
+}
+
+// Key 返回锁的 key
+func (l *Redlock) Key() string {
+	return l.key
+}
+
+// Value 返回锁持有者标识
+func (l *Redlock) Value() string {
+	return l.value
+}
+
+// quorum 多数派节点数
+func (l *Redlock) quorum() int {
+	return len(l.clients)/2 + 1
+}
+
+// Lock 阻塞获取锁，按重试策略重试直到获取成功或 ctx 结束
+func (l *Redlock) Lock(ctx context.Context) error {
+	return l.obs.lock(ctx, func() error {
+		return l.lock(ctx)
+	})
+}
+
+func (l *Redlock) lock(ctx context.Context) error {
+	return retryLoop(ctx, l.retry, nil, func() (bool, error) {
+		return l.tryLock(ctx)
+	})
+}
+
+// TryLock 尝试在所有节点上获取一次锁，未达到多数派或有效期不足时释放已获取的节点
+func (l *Redlock) TryLock(ctx context.Context) (bool, error) {
+	return l.obs.tryLock(ctx, func() (bool, error) {
+		return l.tryLock(ctx)
+	})
+}
+
+func (l *Redlock) tryLock(ctx context.Context) (bool, error) {
+	start := time.Now()
+	acquired := l.onNodes(ctx, func(ctx context.Context, rdb redis.UniversalClient) (bool, error) {
+		return rdb.SetNX(ctx, l.key, l.value, l.ttl).Result()
+	})
+
+	validity := l.ttl - time.Since(start) - redlockDrift(l.ttl)
+	if acquired >= l.quorum() && validity > 0 {
+		l.mu.Lock()
+		l.until = start.Add(validity)
+		l.mu.Unlock()
+		l.watchdog.start(ctx)
+		return true, nil
+	}
+
+	l.release(ctx)
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	return false, nil
+}
+
+// Unlock 在所有节点上释放锁
+func (l *Redlock) Unlock(ctx context.Context) error {
+	return l.obs.unlock(ctx, l.unlock(ctx))
+}
+
+func (l *Redlock) unlock(ctx context.Context) error {
+	l.watchdog.stop()
+
+	l.mu.Lock()
+	l.until = time.Time{}
+	l.mu.Unlock()
+
+	if l.release(ctx) == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// Refresh 在所有节点上延长锁的过期时间，未达到多数派时返回 ErrLockNotHeld
+func (l *Redlock) Refresh(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = l.ttl
+	}
+	start := time.Now()
+	refreshed := l.onNodes(ctx, func(ctx context.Context, rdb redis.UniversalClient) (bool, error) {
+		res, err := refreshScript.Run(ctx, rdb, []string{l.key}, l.value, ttl.Milliseconds()).Int64()
+		return res == 1, err
+	})
+
+	validity := ttl - time.Since(start) - redlockDrift(ttl)
+	if refreshed < l.quorum() || validity <= 0 {
+		return ErrLockNotHeld
+	}
+	l.mu.Lock()
+	l.until = start.Add(validity)
+	l.mu.Unlock()
+	return nil
+}
+
+// TTL 返回锁的剩余有效期（已扣除获取耗时和时钟漂移）
+func (l *Redlock) TTL(ctx context.Context) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if ttl := time.Until(l.until); ttl > 0 {
+		return ttl, nil
+	}
+	return 0, nil
+}
+
+// Lost 返回锁丢失信号：看门狗续期未达到多数派时关闭，未开启 AutoRenew 时永远不会关闭
+func (l *Redlock) Lost() <-chan struct{} {
+	return l.watchdog.Lost()
+}
+
+// redlockDrift 时钟漂移补偿：TTL 的 1% 再加 2ms
+func redlockDrift(ttl time.Duration) time.Duration {
+	return time.Duration(float64(ttl)*redlockDriftFactor) + 2*time.Millisecond
+}
+
+// release 在所有节点上释放锁，返回释放成功的节点数
+func (l *Redlock) release(ctx context.Context) int {
+	// ctx 可能已经结束，释放时使用独立的 ctx，尽量不留下残余的锁
+	ctx = context.WithoutCancel(ctx)
+	return l.onNodes(ctx, func(ctx context.Context, rdb redis.UniversalClient) (bool, error) {
+		res, err := releaseScript.Run(ctx, rdb, []string{l.key}, l.value, ReleaseChannel(l.key)).Int64()
+		return res == 1, err
+	})
+}
+
+// onNodes 并发在所有节点上执行 fn，返回成功的节点数；单个节点出错视为失败
+func (l *Redlock) onNodes(ctx context.Context, fn func(ctx context.Context, rdb redis.UniversalClient) (bool, error)) int {
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+		n  int
+	)
+	for _, rdb := range l.clients {
+		wg.Add(1)
+		go func(rdb redis.UniversalClient) {
+			defer wg.Done()
+
+			nodeCtx, cancel := context.WithTimeout(ctx, l.nodeTimeout)
+			defer cancel()
+			if ok, err := fn(nodeCtx, rdb); err == nil && ok {
+				mu.Lock()
+				n++
+				mu.Unlock()
+			}
+		}(rdb)
+	}
+	wg.Wait()
+	return n
+}
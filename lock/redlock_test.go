@@ -0,0 +1,160 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// 启动 n 个相互独立的 miniredis 节点
+func newRedlockNodes(t *testing.T, n int) ([]*miniredis.Miniredis, []redis.UniversalClient) {
+	t.Helper()
+
+	servers := make([]*miniredis.Miniredis, n)
+	clients := make([]redis.UniversalClient, n)
+	for i := 0; i < n; i++ {
+		servers[i] = miniredis.RunT(t)
+		rdb := redis.NewClient(&redis.Options{Addr: servers[i].Addr(), MaxRetries: -1})
+		t.Cleanup(func() { rdb.Close() })
+		clients[i] = rdb
+	}
+	return servers, clients
+}
+
+func TestRedlockMutualExclusion(t *testing.T) {
+	ctx := context.Background()
+	servers, clients := newRedlockNodes(t, 5)
+
+	locker := NewRedlock(clients, "test_redlock", &Options{TTL: 5 * time.Second})
+	if err := locker.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+	for i, s := range servers {
+		if v, _ := s.Get("test_redlock"); v != locker.Value() {
+			t.Errorf("节点%d 上的锁值不正确: %q", i, v)
+		}
+	}
+	if ttl, _ := locker.TTL(ctx); ttl <= 0 || ttl > 5*time.Second {
+		t.Errorf("锁有效期不正确: %v", ttl)
+	}
+
+	other := NewRedlock(clients, "test_redlock", &Options{Retry: NoRetry()})
+	if err := other.Lock(ctx); !errors.Is(err, ErrNotObtained) {
+		t.Errorf("期望 ErrNotObtained，实际: %v", err)
+	}
+
+	if err := locker.Refresh(ctx, 10*time.Second); err != nil {
+		t.Fatalf("续期失败: %v", err)
+	}
+	if err := locker.Unlock(ctx); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+	for i, s := range servers {
+		if s.Exists("test_redlock") {
+			t.Errorf("释放后节点%d 上仍残留锁", i)
+		}
+	}
+}
+
+func TestRedlockQuorum(t *testing.T) {
+	ctx := context.Background()
+	servers, clients := newRedlockNodes(t, 5)
+
+	// 少数节点宕机时仍能获取
+	servers[0].Close()
+	servers[1].Close()
+	locker := NewRedlock(clients, "test_redlock_quorum", &Options{TTL: 5 * time.Second, Retry: NoRetry()})
+	if err := locker.Lock(ctx); err != nil {
+		t.Fatalf("多数节点存活时应获取成功: %v", err)
+	}
+	if err := locker.Unlock(ctx); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+
+	// 多数节点被其他持有者占用时获取失败，并释放已获取的少数节点
+	for _, s := range servers[2:4] {
+		s.Set("test_redlock_quorum", "other_owner")
+	}
+	if err := locker.Lock(ctx); !errors.Is(err, ErrNotObtained) {
+		t.Errorf("未达到多数派时应获取失败，实际: %v", err)
+	}
+	if servers[4].Exists("test_redlock_quorum") {
+		t.Error("获取失败后应释放已获取的节点")
+	}
+}
+
+func TestRedlockNodeTimeout(t *testing.T) {
+	ctx := context.Background()
+	_, clients := newRedlockNodes(t, 2)
+
+	if got := NewRedlock(clients, "k", &Options{TTL: 10 * time.Second}).nodeTimeout; got != 100*time.Millisecond {
+		t.Errorf("默认节点超时应为 TTL 的 1%%，实际: %v", got)
+	}
+	if got := NewRedlock(clients, "k", &Options{TTL: time.Second}).nodeTimeout; got != DefaultNodeTimeout {
+		t.Errorf("默认节点超时不应小于 DefaultNodeTimeout，实际: %v", got)
+	}
+
+	// 只监听不处理请求的节点（连接停留在 accept 队列中），请求在 NodeTimeout 后放弃
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	hang := redis.NewClient(&redis.Options{Addr: ln.Addr().String(), MaxRetries: -1})
+	t.Cleanup(func() { hang.Close() })
+
+	locker := NewRedlock(append(clients, hang), "test_redlock_timeout", &Options{
+		TTL:         10 * time.Second,
+		NodeTimeout: 20 * time.Millisecond,
+	})
+	start := time.Now()
+	if ok, err := locker.TryLock(ctx); err != nil || !ok {
+		t.Fatalf("多数节点可用时应获取成功: %v, %v", ok, err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("无响应的节点阻塞了 %v，应在 NodeTimeout 后放弃", elapsed)
+	}
+}
+
+func TestRedlockAutoRenew(t *testing.T) {
+	ctx := context.Background()
+	servers, clients := newRedlockNodes(t, 3)
+
+	locker := NewRedlock(clients, "test_redlock_renew", &Options{
+		TTL:           time.Second,
+		AutoRenew:     true,
+		RenewInterval: 20 * time.Millisecond,
+	})
+	if err := locker.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+
+	// 看门狗应把各节点上即将过期的锁续回 TTL
+	for _, s := range servers {
+		s.SetTTL("test_redlock_renew", 10*time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	for i, s := range servers {
+		if ttl := s.TTL("test_redlock_renew"); ttl <= 10*time.Millisecond {
+			t.Errorf("节点%d 上的锁未被续期: %v", i, ttl)
+		}
+	}
+
+	// 多数节点上的锁被其他持有者占用后，续期失败并发出锁丢失信号
+	for _, s := range servers[:2] {
+		s.Set("test_redlock_renew", "other_owner")
+	}
+	select {
+	case <-locker.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("续期未达到多数派时未收到锁丢失信号")
+	}
+	if err := locker.Unlock(ctx); err != nil {
+		t.Errorf("仍有节点持有锁，释放应成功: %v", err)
+	}
+}
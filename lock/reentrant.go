@@ -0,0 +1,173 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ReentrantLock 可重入的 Redis 分布式锁。
+// 锁保存为 Redis hash：field 为持有者标识，value 为持有次数。
+// 同一持有者（相同 Options.Value）可以重复获取，需要 Unlock 相同次数后才真正释放
+type ReentrantLock struct {
+	client *Client
+	key    string
+	value  string
+	ttl    time.Duration
+	retry  RetryStrategy
+
+	wakeOnRelease bool
+	watchdog      *watchdog
+	obs           *observation
+
+	mu    sync.Mutex
+	holds int // 本实例的持有次数，用于控制看门狗的启停
+}
+
+var _ Locker = (*ReentrantLock)(nil)
+
+// NewReentrantLock 创建指定 key 的可重入锁，此时并不会去获取锁
+func (c *Client) NewReentrantLock(key string, opts *Options) *ReentrantLock {
+	o := opts.withDefaults()
+	l := &ReentrantLock{
+		client:        c,
+		key:           key,
+		value:         o.Value,
+		ttl:           o.TTL,
+		retry:         o.Retry,
+		wakeOnRelease: o.WakeOnRelease,
+		obs:           newObservation(o, key),
+	}
+	l.watchdog = newWatchdog(o, l.Refresh, l.obs)
+	return l
+}
+
+// Key 返回锁的 key
+func (l *ReentrantLock) Key() string {
+	return l.key
+}
+
+// Value 返回锁持有者标识
+func (l *ReentrantLock) Value() string {
+	return l.value
+}
+
+// Lock 阻塞获取锁，按重试策略重试直到获取成功或 ctx 结束
+func (l *ReentrantLock) Lock(ctx context.Context) error {
+	return l.obs.lock(ctx, func() error {
+		return l.lock(ctx)
+	})
+}
+
+func (l *ReentrantLock) lock(ctx context.Context) error {
+	var wake <-chan *redis.Message
+	if l.wakeOnRelease {
+		sub, ch, err := subscribeRelease(ctx, l.client.rdb, l.key)
+		if err != nil {
+			return err
+		}
+		defer sub.Close()
+		wake = ch
+	}
+	return retryLoop(ctx, l.retry, wake, func() (bool, error) {
+		return l.tryLock(ctx)
+	})
+}
+
+// TryLock 尝试获取一次锁，已由同一持有者持有时持有次数 +1
+func (l *ReentrantLock) TryLock(ctx context.Context) (bool, error) {
+	return l.obs.tryLock(ctx, func() (bool, error) {
+		return l.tryLock(ctx)
+	})
+}
+
+func (l *ReentrantLock) tryLock(ctx context.Context) (bool, error) {
+	count, err := reentrantAcquireScript.Run(ctx, l.client.rdb, []string{l.key}, l.value, l.ttl.Milliseconds()).Int64()
+	if err != nil || count == 0 {
+		return false, err
+	}
+
+	l.mu.Lock()
+	l.holds++
+	first := l.holds == 1
+	l.mu.Unlock()
+	if first {
+		l.watchdog.start(ctx)
+	}
+	return true, nil
+}
+
+// Unlock 持有次数 -1，减到 0 时释放锁。
+// 释放失败（如网络错误）时本地持有次数和看门狗都保持不变，可以重试 Unlock
+func (l *ReentrantLock) Unlock(ctx context.Context) error {
+	count, err := reentrantReleaseScript.Run(ctx, l.client.rdb, []string{l.key},
+		l.value, l.ttl.Milliseconds(), ReleaseChannel(l.key)).Int64()
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	if count <= 0 {
+		// 锁已真正释放，或 Redis 中已不再由当前持有者持有（过期或被强制释放）
+		l.holds = 0
+	} else if l.holds > 0 {
+		l.holds--
+	}
+	last := l.holds == 0
+	l.mu.Unlock()
+	if last {
+		l.watchdog.stop()
+	}
+
+	if count < 0 {
+		return l.obs.unlock(ctx, ErrLockNotHeld)
+	}
+	if count == 0 {
+		// 持有次数减到 0 才算一次持有结束
+		return l.obs.unlock(ctx, nil)
+	}
+	return nil
+}
+
+// Refresh 延长锁的过期时间
+func (l *ReentrantLock) Refresh(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = l.ttl
+	}
+	res, err := reentrantRefreshScript.Run(ctx, l.client.rdb, []string{l.key}, l.value, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if res != 1 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// TTL 返回锁的剩余过期时间
+func (l *ReentrantLock) TTL(ctx context.Context) (time.Duration, error) {
+	res, err := reentrantPTTLScript.Run(ctx, l.client.rdb, []string{l.key}, l.value).Int64()
+	if err != nil {
+		return 0, err
+	}
+	if res <= 0 {
+		return 0, nil
+	}
+	return time.Duration(res) * time.Millisecond, nil
+}
+
+// HoldCount 返回当前持有者在 Redis 中记录的持有次数，未持有时返回 0
+func (l *ReentrantLock) HoldCount(ctx context.Context) (int64, error) {
+	count, err := l.client.rdb.HGet(ctx, l.key, l.value).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return count, err
+}
+
+// Lost 返回锁丢失信号：看门狗续期失败时关闭
+func (l *ReentrantLock) Lost() <-chan struct{} {
+	return l.watchdog.Lost()
+}
@@ -0,0 +1,100 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"goRedisLock/internal/redistest"
+)
+
+func TestReentrantLockNested(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, "mylock")
+
+	outer := client.NewReentrantLock("mylock", &Options{TTL: 5 * time.Second, Value: "owner_1"})
+	if err := outer.Lock(ctx); err != nil {
+		t.Fatalf("外层获取锁失败: %v", err)
+	}
+
+	// 嵌套函数以同一持有者身份再次获取，不应与自己死锁
+	inner := client.NewReentrantLock("mylock", &Options{TTL: 5 * time.Second, Value: "owner_1", Retry: NoRetry()})
+	if err := inner.Lock(ctx); err != nil {
+		t.Fatalf("内层重入获取锁失败: %v", err)
+	}
+	if count, _ := outer.HoldCount(ctx); count != 2 {
+		t.Errorf("期望持有次数为2，实际为%d", count)
+	}
+
+	// 其他持有者无法获取
+	other := client.NewReentrantLock("mylock", &Options{Value: "owner_2", Retry: NoRetry()})
+	if err := other.Lock(ctx); !errors.Is(err, ErrNotObtained) {
+		t.Errorf("期望 ErrNotObtained，实际: %v", err)
+	}
+	if err := other.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("非持有者释放应返回 ErrLockNotHeld，实际: %v", err)
+	}
+
+	// 释放一次后仍然持有
+	if err := inner.Unlock(ctx); err != nil {
+		t.Fatalf("内层释放锁失败: %v", err)
+	}
+	if ok, err := other.TryLock(ctx); err != nil || ok {
+		t.Errorf("只释放一次后锁应仍被持有: %v, %v", ok, err)
+	}
+
+	// 释放相同次数后才真正释放
+	if err := outer.Unlock(ctx); err != nil {
+		t.Fatalf("外层释放锁失败: %v", err)
+	}
+	if ok, err := other.TryLock(ctx); err != nil || !ok {
+		t.Errorf("完全释放后其他持有者应能获取锁: %v, %v", ok, err)
+	}
+	if err := other.Unlock(ctx); err != nil {
+		t.Errorf("释放锁失败: %v", err)
+	}
+}
+
+// 释放失败时不减少持有次数，看门狗继续续期
+func TestReentrantLockUnlockFailureKeepsRenewal(t *testing.T) {
+	ctx := context.Background()
+	server := redistest.New(t, "test_reentrant_renew_lock")
+	client := NewClient(server.Client)
+
+	locker := client.NewReentrantLock("test_reentrant_renew_lock", &Options{
+		TTL:           300 * time.Millisecond,
+		AutoRenew:     true,
+		RenewInterval: 50 * time.Millisecond,
+	})
+	if err := locker.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := locker.Unlock(cancelled); err == nil {
+		t.Fatal("ctx 已取消时释放应失败")
+	}
+
+	// 累计快进远超 TTL，看门狗仍在续期时锁不会过期
+	for i := 0; i < 5; i++ {
+		time.Sleep(100 * time.Millisecond)
+		server.FastForward(100 * time.Millisecond)
+	}
+	select {
+	case <-locker.Lost():
+		t.Fatal("释放失败后看门狗不应停止")
+	default:
+	}
+	if count, _ := locker.HoldCount(ctx); count != 1 {
+		t.Fatalf("释放失败后持有次数应保持为1，实际: %d", count)
+	}
+
+	if err := locker.Unlock(ctx); err != nil {
+		t.Fatalf("重试释放失败: %v", err)
+	}
+	if count, _ := locker.HoldCount(ctx); count != 0 {
+		t.Errorf("释放后持有次数应为0，实际: %d", count)
+	}
+}
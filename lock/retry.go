@@ -0,0 +1,131 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RetryStrategy 阻塞获取锁时的重试策略
+type RetryStrategy interface {
+	// Backoff 返回第 attempt 次（从 1 开始）获取失败后的等待时间，返回 0 表示不再重试
+	Backoff(attempt int) time.Duration
+}
+
+// NoRetry 获取失败后立即返回，不再重试
+func NoRetry() RetryStrategy {
+	return noRetry{}
+}
+
+type noRetry struct{}
+
+func (noRetry) Backoff(int) time.Duration {
+	return 0
+}
+
+// FixedBackoff 每次固定等待 interval 后重试
+func FixedBackoff(interval time.Duration) RetryStrategy {
+	return fixedBackoff{interval: interval}
+}
+
+type fixedBackoff struct {
+	interval time.Duration
+}
+
+func (s fixedBackoff) Backoff(int) time.Duration {
+	return s.interval
+}
+
+// LinearBackoff 等待时间按 step 线性增长，不超过 max（max<=0 表示不限制）
+func LinearBackoff(step, max time.Duration) RetryStrategy {
+	return linearBackoff{step: step, max: max}
+}
+
+type linearBackoff struct {
+	step, max time.Duration
+}
+
+func (s linearBackoff) Backoff(attempt int) time.Duration {
+	d := time.Duration(attempt) * s.step
+	if s.max > 0 && d > s.max {
+		d = s.max
+	}
+	return d
+}
+
+// ExponentialBackoff 等待时间从 min 开始指数增长直到 max，并加入随机抖动，
+// 避免大量等待者在同一时刻重试
+func ExponentialBackoff(min, max time.Duration) RetryStrategy {
+	return exponentialBackoff{min: min, max: max}
+}
+
+type exponentialBackoff struct {
+	min, max time.Duration
+}
+
+func (s exponentialBackoff) Backoff(attempt int) time.Duration {
+	d := s.min
+	for i := 1; i < attempt && d < s.max; i++ {
+		d *= 2
+	}
+	if d > s.max {
+		d = s.max
+	}
+	// 抖动：在 [d/2, d) 之间随机
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + time.Duration(rand.Int63n(int64(half)))
+}
+
+// LimitRetry 最多重试 max 次
+func LimitRetry(s RetryStrategy, max int) RetryStrategy {
+	return limitRetry{s: s, max: max}
+}
+
+type limitRetry struct {
+	s   RetryStrategy
+	max int
+}
+
+func (s limitRetry) Backoff(attempt int) time.Duration {
+	if attempt > s.max {
+		return 0
+	}
+	return s.s.Backoff(attempt)
+}
+
+// retryLoop 按重试策略反复调用 try，直到获取成功、策略放弃或 ctx 结束；
+// 等待期间从 wake 收到锁释放通知时立即重试
+func retryLoop(ctx context.Context, retry RetryStrategy, wake <-chan *redis.Message, try func() (bool, error)) error {
+	for attempt := 1; ; attempt++ {
+		ok, err := try()
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("%w: %w", ErrNotObtained, ctx.Err())
+			}
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		backoff := retry.Backoff(attempt)
+		if backoff <= 0 {
+			return ErrNotObtained
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: %w", ErrNotObtained, ctx.Err())
+		case <-wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRetryStrategies(t *testing.T) {
+	if d := NoRetry().Backoff(1); d != 0 {
+		t.Errorf("NoRetry 不应重试，实际等待 %v", d)
+	}
+	if d := FixedBackoff(100 * time.Millisecond).Backoff(5); d != 100*time.Millisecond {
+		t.Errorf("FixedBackoff 等待时间不正确: %v", d)
+	}
+
+	linear := LinearBackoff(10*time.Millisecond, 25*time.Millisecond)
+	for attempt, want := range map[int]time.Duration{1: 10 * time.Millisecond, 2: 20 * time.Millisecond, 3: 25 * time.Millisecond} {
+		if d := linear.Backoff(attempt); d != want {
+			t.Errorf("LinearBackoff 第%d次期望 %v，实际 %v", attempt, want, d)
+		}
+	}
+
+	exp := ExponentialBackoff(10*time.Millisecond, 80*time.Millisecond)
+	for attempt := 1; attempt <= 10; attempt++ {
+		d := exp.Backoff(attempt)
+		if d < 5*time.Millisecond || d >= 80*time.Millisecond {
+			t.Errorf("ExponentialBackoff 第%d次等待时间超出范围: %v", attempt, d)
+		}
+	}
+
+	limited := LimitRetry(FixedBackoff(time.Millisecond), 2)
+	if limited.Backoff(2) == 0 || limited.Backoff(3) != 0 {
+		t.Error("LimitRetry 应在重试 2 次后放弃")
+	}
+}
+
+func TestLockBlocksUntilReleased(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, "test_blocking_lock")
+
+	holder := client.NewLock("test_blocking_lock", &Options{TTL: 5 * time.Second})
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		time.Sleep(200 * time.Millisecond)
+		if err := holder.Unlock(ctx); err != nil {
+			t.Errorf("释放锁失败: %v", err)
+		}
+	}()
+
+	waiter := client.NewLock("test_blocking_lock", &Options{
+		TTL:   5 * time.Second,
+		Retry: FixedBackoff(20 * time.Millisecond),
+	})
+	waitCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := waiter.Lock(waitCtx); err != nil {
+		t.Fatalf("等待者未能在锁释放后获取锁: %v", err)
+	}
+	t.Logf("等待 %v 后获取锁", time.Since(start))
+	wg.Wait()
+
+	if err := waiter.Unlock(ctx); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+}
+
+func TestLockGivesUpOnDeadline(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, "test_deadline_lock")
+
+	holder := client.NewLock("test_deadline_lock", &Options{TTL: 5 * time.Second})
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+
+	waiter := client.NewLock("test_deadline_lock", &Options{Retry: FixedBackoff(20 * time.Millisecond)})
+	waitCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	err := waiter.Lock(waitCtx)
+	if !errors.Is(err, ErrNotObtained) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("期望超时返回 ErrNotObtained，实际: %v", err)
+	}
+
+	// 不重试时立即返回
+	noRetry := client.NewLock("test_deadline_lock", &Options{Retry: NoRetry()})
+	if err := noRetry.Lock(ctx); !errors.Is(err, ErrNotObtained) {
+		t.Errorf("期望 ErrNotObtained，实际: %v", err)
+	}
+}
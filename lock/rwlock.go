@@ -0,0 +1,164 @@
+package lock
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RWLocker 分布式读写锁接口：多个读者可以同时持有读锁，写锁与任何读写互斥
+type RWLocker interface {
+	// RLock 阻塞获取读锁
+	RLock(ctx context.Context) error
+	// TryRLock 尝试获取一次读锁
+	TryRLock(ctx context.Context) (bool, error)
+	// RUnlock 释放读锁，不是读锁持有者时返回 ErrLockNotHeld
+	RUnlock(ctx context.Context) error
+	// Lock 阻塞获取写锁
+	Lock(ctx context.Context) error
+	// TryLock 尝试获取一次写锁
+	TryLock(ctx context.Context) (bool, error)
+	// Unlock 释放写锁，不是写锁持有者时返回 ErrLockNotHeld
+	Unlock(ctx context.Context) error
+}
+
+// RWLock 基于 Redis 的读写锁。
+// 写锁保存在 key 上；每个读者在 key:readers 有序集合中拥有独立到期的租约；
+// 等待中的写者登记在 key:writer_waiting 上，登记后新读者无法进入，避免写者饥饿
+type RWLock struct {
+	client *Client
+	key    string
+	value  string
+	ttl    time.Duration
+	retry  RetryStrategy
+
+	wakeOnRelease bool
+	readObs       *observation // 读锁的事件上报
+	writeObs      *observation // 写锁的事件上报
+}
+
+var _ RWLocker = (*RWLock)(nil)
+
+// NewRWLock 创建指定 key 的读写锁，此时并不会去获取锁
+func (c *Client) NewRWLock(key string, opts *Options) *RWLock {
+	o := opts.withDefaults()
+	return &RWLock{
+		client:        c,
+		key:           key,
+		value:         o.Value,
+		ttl:           o.TTL,
+		retry:         o.Retry,
+		wakeOnRelease: o.WakeOnRelease,
+		readObs:       newObservation(o, key),
+		writeObs:      newObservation(o, key),
+	}
+}
+
+// Key 返回锁的 key
+func (l *RWLock) Key() string {
+	return l.key
+}
+
+// Value 返回锁持有者标识
+func (l *RWLock) Value() string {
+	return l.value
+}
+
+// RLock 阻塞获取读锁
+func (l *RWLock) RLock(ctx context.Context) error {
+	return l.readObs.lock(ctx, func() error {
+		return l.wait(ctx, func() (bool, error) {
+			return l.tryRLock(ctx)
+		})
+	})
+}
+
+// TryRLock 尝试获取一次读锁，已持有读锁时延长租约
+func (l *RWLock) TryRLock(ctx context.Context) (bool, error) {
+	return l.readObs.tryLock(ctx, func() (bool, error) {
+		return l.tryRLock(ctx)
+	})
+}
+
+func (l *RWLock) tryRLock(ctx context.Context) (bool, error) {
+	keys := []string{l.key, ReadersKey(l.key), WriterWaitingKey(l.key)}
+	res, err := rlockAcquireScript.Run(ctx, l.client.rdb, keys, l.value, l.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+// RUnlock 释放读锁
+func (l *RWLock) RUnlock(ctx context.Context) error {
+	res, err := rlockReleaseScript.Run(ctx, l.client.rdb, []string{ReadersKey(l.key)}, l.value, ReleaseChannel(l.key)).Int64()
+	if err == nil && res != 1 {
+		err = ErrLockNotHeld
+	}
+	return l.readObs.unlock(ctx, err)
+}
+
+// Lock 阻塞获取写锁，放弃等待时撤销写意向
+func (l *RWLock) Lock(ctx context.Context) error {
+	return l.writeObs.lock(ctx, func() error {
+		return l.lock(ctx)
+	})
+}
+
+func (l *RWLock) lock(ctx context.Context) error {
+	err := l.wait(ctx, func() (bool, error) {
+		return l.tryLock(ctx, true)
+	})
+	if err != nil {
+		// ctx 可能已经结束，使用独立的 ctx 撤销写意向
+		cancelCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second)
+		defer cancel()
+		wlockCancelIntentScript.Run(cancelCtx, l.client.rdb, []string{WriterWaitingKey(l.key)}, l.value)
+	}
+	return err
+}
+
+// TryLock 尝试获取一次写锁，获取失败时不登记写意向
+func (l *RWLock) TryLock(ctx context.Context) (bool, error) {
+	return l.writeObs.tryLock(ctx, func() (bool, error) {
+		return l.tryLock(ctx, false)
+	})
+}
+
+// tryLock 获取一次写锁，intent 为 true 时获取失败后登记写意向，由 Lock 在放弃时撤销
+func (l *RWLock) tryLock(ctx context.Context, intent bool) (bool, error) {
+	flag := 0
+	if intent {
+		flag = 1
+	}
+	keys := []string{l.key, ReadersKey(l.key), WriterWaitingKey(l.key)}
+	res, err := wlockAcquireScript.Run(ctx, l.client.rdb, keys, l.value, l.ttl.Milliseconds(), flag).Int64()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+// Unlock 释放写锁
+func (l *RWLock) Unlock(ctx context.Context) error {
+	res, err := releaseScript.Run(ctx, l.client.rdb, []string{l.key}, l.value, ReleaseChannel(l.key)).Int64()
+	if err == nil && res != 1 {
+		err = ErrLockNotHeld
+	}
+	return l.writeObs.unlock(ctx, err)
+}
+
+// wait 按重试策略等待，开启 WakeOnRelease 时收到释放通知立即重试
+func (l *RWLock) wait(ctx context.Context, try func() (bool, error)) error {
+	var wake <-chan *redis.Message
+	if l.wakeOnRelease {
+		sub, ch, err := subscribeRelease(ctx, l.client.rdb, l.key)
+		if err != nil {
+			return err
+		}
+		defer sub.Close()
+		wake = ch
+	}
+	return retryLoop(ctx, l.retry, wake, try)
+}
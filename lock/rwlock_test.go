@@ -0,0 +1,140 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRWLockReadersShare(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, "test_rw_lock", ReadersKey("test_rw_lock"), WriterWaitingKey("test_rw_lock"))
+
+	reader1 := client.NewRWLock("test_rw_lock", &Options{TTL: 5 * time.Second})
+	reader2 := client.NewRWLock("test_rw_lock", &Options{TTL: 5 * time.Second})
+	writer := client.NewRWLock("test_rw_lock", &Options{TTL: 5 * time.Second, Retry: NoRetry()})
+
+	if err := reader1.RLock(ctx); err != nil {
+		t.Fatalf("读者1获取读锁失败: %v", err)
+	}
+	if err := reader2.RLock(ctx); err != nil {
+		t.Fatalf("读者2获取读锁失败: %v", err)
+	}
+
+	// 有读者时写者无法获取，并登记写意向
+	if err := writer.Lock(ctx); !errors.Is(err, ErrNotObtained) {
+		t.Errorf("有读者时写者应获取失败，实际: %v", err)
+	}
+
+	if err := reader1.RUnlock(ctx); err != nil {
+		t.Fatalf("读者1释放读锁失败: %v", err)
+	}
+	if err := reader1.RUnlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("重复释放读锁应返回 ErrLockNotHeld，实际: %v", err)
+	}
+	if err := reader2.RUnlock(ctx); err != nil {
+		t.Fatalf("读者2释放读锁失败: %v", err)
+	}
+
+	// 读者全部离开后写者可以获取
+	if err := writer.Lock(ctx); err != nil {
+		t.Fatalf("写者获取写锁失败: %v", err)
+	}
+	if ok, err := reader1.TryRLock(ctx); err != nil || ok {
+		t.Errorf("写锁持有期间读者不应获取成功: %v, %v", ok, err)
+	}
+	if err := reader1.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("非持有者释放写锁应返回 ErrLockNotHeld，实际: %v", err)
+	}
+	if err := writer.Unlock(ctx); err != nil {
+		t.Fatalf("写者释放写锁失败: %v", err)
+	}
+}
+
+func TestRWLockWriterPreference(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, "test_rw_pref_lock", ReadersKey("test_rw_pref_lock"), WriterWaitingKey("test_rw_pref_lock"))
+
+	reader := client.NewRWLock("test_rw_pref_lock", &Options{TTL: 5 * time.Second})
+	writer := client.NewRWLock("test_rw_pref_lock", &Options{TTL: 5 * time.Second})
+	if err := reader.RLock(ctx); err != nil {
+		t.Fatalf("获取读锁失败: %v", err)
+	}
+
+	// 写者登记写意向后，新读者不能再进入（tryLock(ctx, true) 相当于 Lock 的一次重试）
+	if ok, err := writer.tryLock(ctx, true); err != nil || ok {
+		t.Fatalf("有读者时写者不应获取成功: %v, %v", ok, err)
+	}
+	newReader := client.NewRWLock("test_rw_pref_lock", &Options{TTL: 5 * time.Second})
+	if ok, err := newReader.TryRLock(ctx); err != nil || ok {
+		t.Errorf("写者等待期间新读者不应获取成功: %v, %v", ok, err)
+	}
+	// 已持有读锁的读者仍可续租
+	if ok, err := reader.TryRLock(ctx); err != nil || !ok {
+		t.Errorf("已有读者续租失败: %v, %v", ok, err)
+	}
+
+	if err := reader.RUnlock(ctx); err != nil {
+		t.Fatalf("释放读锁失败: %v", err)
+	}
+	if ok, err := writer.TryLock(ctx); err != nil || !ok {
+		t.Fatalf("读者离开后等待中的写者应获取成功: %v, %v", ok, err)
+	}
+	if err := writer.Unlock(ctx); err != nil {
+		t.Fatalf("释放写锁失败: %v", err)
+	}
+	if ok, err := newReader.TryRLock(ctx); err != nil || !ok {
+		t.Errorf("写者释放后读者应获取成功: %v, %v", ok, err)
+	}
+}
+
+// TryLock 失败不登记写意向，不会阻塞之后的读者
+func TestRWLockTryLockNoIntent(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, "test_rw_try_lock", ReadersKey("test_rw_try_lock"), WriterWaitingKey("test_rw_try_lock"))
+
+	reader := client.NewRWLock("test_rw_try_lock", &Options{TTL: 5 * time.Second})
+	writer := client.NewRWLock("test_rw_try_lock", &Options{TTL: 5 * time.Second})
+	if err := reader.RLock(ctx); err != nil {
+		t.Fatalf("获取读锁失败: %v", err)
+	}
+	if ok, err := writer.TryLock(ctx); err != nil || ok {
+		t.Fatalf("有读者时写者不应获取成功: %v, %v", ok, err)
+	}
+	if err := reader.RUnlock(ctx); err != nil {
+		t.Fatalf("释放读锁失败: %v", err)
+	}
+
+	next := client.NewRWLock("test_rw_try_lock", &Options{TTL: 5 * time.Second})
+	if ok, err := next.TryRLock(ctx); err != nil || !ok {
+		t.Fatalf("没有持有者和等待者时读者应获取成功: %v, %v", ok, err)
+	}
+	if err := next.RUnlock(ctx); err != nil {
+		t.Fatalf("释放读锁失败: %v", err)
+	}
+}
+
+func TestRWLockReaderLeaseExpires(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, "test_rw_expire_lock", ReadersKey("test_rw_expire_lock"), WriterWaitingKey("test_rw_expire_lock"))
+
+	// 读者崩溃后不释放，租约到期后写者可以获取
+	crashed := client.NewRWLock("test_rw_expire_lock", &Options{TTL: 100 * time.Millisecond})
+	if err := crashed.RLock(ctx); err != nil {
+		t.Fatalf("获取读锁失败: %v", err)
+	}
+
+	writer := client.NewRWLock("test_rw_expire_lock", &Options{
+		TTL:   5 * time.Second,
+		Retry: FixedBackoff(20 * time.Millisecond),
+	})
+	waitCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if err := writer.Lock(waitCtx); err != nil {
+		t.Fatalf("读租约到期后写者应获取成功: %v", err)
+	}
+	if err := writer.Unlock(ctx); err != nil {
+		t.Fatalf("释放写锁失败: %v", err)
+	}
+}
@@ -0,0 +1,347 @@
+package lock
+
+import "github.com/go-redis/redis/v8"
+
+// 获取锁：SET NX 成功后递增 KEYS[2] 上的 fencing token 计数器并返回新 token，
+// 锁已被占用时返回 0
+var acquireScript = redis.NewScript(`
+	if redis.call("set", KEYS[1], ARGV[1], "nx", "px", ARGV[2]) then
+		return redis.call("incr", KEYS[2])
+	end
+	return 0
+`)
+
+// 释放锁：只有锁的持有者才能删除，删除后在 ARGV[2] 频道上通知等待者
+var releaseScript = redis.NewScript(`
+	if redis.call("get", KEYS[1]) == ARGV[1] then
+		redis.call("del", KEYS[1])
+		redis.call("publish", ARGV[2], KEYS[1])
+		return 1
+	else
+		return 0
+	end
+`)
+
+// 续期：只有锁的持有者才能延长过期时间
+var refreshScript = redis.NewScript(`
+	if redis.call("get", KEYS[1]) == ARGV[1] then
+		return redis.call("pexpire", KEYS[1], ARGV[2])
+	else
+		return 0
+	end
+`)
+
+// 查询剩余过期时间：不是锁的持有者时返回 -3
+var pttlScript = redis.NewScript(`
+	if redis.call("get", KEYS[1]) == ARGV[1] then
+		return redis.call("pttl", KEYS[1])
+	else
+		return -3
+	end
+`)
+
+// 可重入锁获取：锁不存在或已由同一持有者持有时，持有次数 +1 并重置过期时间，
+// 返回当前持有次数；被其他持有者占用时返回 0
+var reentrantAcquireScript = redis.NewScript(`
+	if redis.call("exists", KEYS[1]) == 0 or redis.call("hexists", KEYS[1], ARGV[1]) == 1 then
+		local count = redis.call("hincrby", KEYS[1], ARGV[1], 1)
+		redis.call("pexpire", KEYS[1], ARGV[2])
+		return count
+	end
+	return 0
+`)
+
+// 可重入锁释放：持有次数 -1，减到 0 时删除锁并通知等待者，
+// 返回剩余持有次数；不是锁的持有者时返回 -1
+var reentrantReleaseScript = redis.NewScript(`
+	if redis.call("hexists", KEYS[1], ARGV[1]) == 0 then
+		return -1
+	end
+	local count = redis.call("hincrby", KEYS[1], ARGV[1], -1)
+	if count > 0 then
+		redis.call("pexpire", KEYS[1], ARGV[2])
+		return count
+	end
+	redis.call("del", KEYS[1])
+	redis.call("publish", ARGV[3], KEYS[1])
+	return 0
+`)
+
+// 可重入锁续期
+var reentrantRefreshScript = redis.NewScript(`
+	if redis.call("hexists", KEYS[1], ARGV[1]) == 1 then
+		return redis.call("pexpire", KEYS[1], ARGV[2])
+	else
+		return 0
+	end
+`)
+
+// 可重入锁剩余过期时间：不是锁的持有者时返回 -3
+var reentrantPTTLScript = redis.NewScript(`
+	if redis.call("hexists", KEYS[1], ARGV[1]) == 1 then
+		return redis.call("pttl", KEYS[1])
+	else
+		return -3
+	end
+`)
+
+// 读锁获取：KEYS[1] 写锁，KEYS[2] 读者集合（score 为租约到期的毫秒时间戳），KEYS[3] 等待中的写者。
+// 先清理过期读者；有写者持有或有写者在等待（写优先）时，新读者获取失败
+var rlockAcquireScript = redis.NewScript(`
+	local t = redis.call("time")
+	local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+	redis.call("zremrangebyscore", KEYS[2], "-inf", now)
+	if redis.call("exists", KEYS[1]) == 1 then
+		return 0
+	end
+	if redis.call("exists", KEYS[3]) == 1 and not redis.call("zscore", KEYS[2], ARGV[1]) then
+		return 0
+	end
+	redis.call("zadd", KEYS[2], now + tonumber(ARGV[2]), ARGV[1])
+	if redis.call("pttl", KEYS[2]) < tonumber(ARGV[2]) then
+		redis.call("pexpire", KEYS[2], ARGV[2])
+	end
+	return 1
+`)
+
+// 读锁释放：只有持有读租约的读者才能释放，最后一个读者离开时通知等待者；
+// 不是读锁持有者时返回 0
+var rlockReleaseScript = redis.NewScript(`
+	if redis.call("zrem", KEYS[1], ARGV[1]) == 0 then
+		return 0
+	end
+	if redis.call("zcard", KEYS[1]) == 0 then
+		redis.call("del", KEYS[1])
+		redis.call("publish", ARGV[2], KEYS[1])
+	end
+	return 1
+`)
+
+// 写锁获取：KEYS 同读锁，ARGV[3] 为 1 时获取失败后登记写意向。
+// 有读者或其他写者时按 ARGV[3] 登记写意向（阻止新读者进入），
+// 只有没有其他写者抢先登记意向时才能获取
+var wlockAcquireScript = redis.NewScript(`
+	local t = redis.call("time")
+	local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+	redis.call("zremrangebyscore", KEYS[2], "-inf", now)
+	local waiting = redis.call("get", KEYS[3])
+	if waiting and waiting ~= ARGV[1] then
+		return 0
+	end
+	if redis.call("exists", KEYS[1]) == 1 or redis.call("zcard", KEYS[2]) > 0 then
+		if ARGV[3] == "1" then
+			redis.call("set", KEYS[3], ARGV[1], "px", ARGV[2])
+		end
+		return 0
+	end
+	redis.call("set", KEYS[1], ARGV[1], "px", ARGV[2])
+	if waiting then
+		redis.call("del", KEYS[3])
+	end
+	return 1
+`)
+
+// 撤销写意向：写者放弃等待时调用，避免读者被阻塞到意向过期
+var wlockCancelIntentScript = redis.NewScript(`
+	if redis.call("get", KEYS[1]) == ARGV[1] then
+		return redis.call("del", KEYS[1])
+	end
+	return 0
+`)
+
+// 信号量获取：KEYS[1] 为持有者有序集合（score 为租约到期的毫秒时间戳），
+// ARGV[1] 持有者标识，ARGV[2] 租约毫秒数，ARGV[3] 许可数。
+// 先清理过期持有者；已持有时续租，否则在许可未用完时加入
+var semAcquireScript = redis.NewScript(`
+	local t = redis.call("time")
+	local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+	redis.call("zremrangebyscore", KEYS[1], "-inf", now)
+	if not redis.call("zscore", KEYS[1], ARGV[1]) and redis.call("zcard", KEYS[1]) >= tonumber(ARGV[3]) then
+		return 0
+	end
+	redis.call("zadd", KEYS[1], now + tonumber(ARGV[2]), ARGV[1])
+	if redis.call("pttl", KEYS[1]) < tonumber(ARGV[2]) then
+		redis.call("pexpire", KEYS[1], ARGV[2])
+	end
+	return 1
+`)
+
+// 信号量释放：移除持有者并通知等待者，不是持有者时返回 0
+var semReleaseScript = redis.NewScript(`
+	if redis.call("zrem", KEYS[1], ARGV[1]) == 0 then
+		return 0
+	end
+	redis.call("publish", ARGV[2], KEYS[1])
+	return 1
+`)
+
+// 信号量续租：租约未过期时延长，否则返回 0
+var semRefreshScript = redis.NewScript(`
+	local t = redis.call("time")
+	local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+	local expiry = redis.call("zscore", KEYS[1], ARGV[1])
+	if not expiry or tonumber(expiry) <= now then
+		return 0
+	end
+	redis.call("zadd", KEYS[1], now + tonumber(ARGV[2]), ARGV[1])
+	if redis.call("pttl", KEYS[1]) < tonumber(ARGV[2]) then
+		redis.call("pexpire", KEYS[1], ARGV[2])
+	end
+	return 1
+`)
+
+// 清理过期的信号量持有者，返回清理的数量
+var semReapScript = redis.NewScript(`
+	local t = redis.call("time")
+	local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+	return redis.call("zremrangebyscore", KEYS[1], "-inf", now)
+`)
+
+// 多 key 获取：所有 KEYS 都未被占用时才全部设置，否则一个都不设置
+var multiAcquireScript = redis.NewScript(`
+	for i = 1, #KEYS do
+		if redis.call("exists", KEYS[i]) == 1 then
+			return 0
+		end
+	end
+	for i = 1, #KEYS do
+		redis.call("set", KEYS[i], ARGV[1], "px", ARGV[2])
+	end
+	return 1
+`)
+
+// 多 key 释放：删除仍由持有者持有的 key 并在 ARGV[i+1] 频道上通知等待者，返回释放的数量
+var multiReleaseScript = redis.NewScript(`
+	local released = 0
+	for i = 1, #KEYS do
+		if redis.call("get", KEYS[i]) == ARGV[1] then
+			redis.call("del", KEYS[i])
+			redis.call("publish", ARGV[i + 1], KEYS[i])
+			released = released + 1
+		end
+	end
+	return released
+`)
+
+// 多 key 续期：所有 key 都仍由持有者持有时才全部续期
+var multiRefreshScript = redis.NewScript(`
+	for i = 1, #KEYS do
+		if redis.call("get", KEYS[i]) ~= ARGV[1] then
+			return 0
+		end
+	end
+	for i = 1, #KEYS do
+		redis.call("pexpire", KEYS[i], ARGV[2])
+	end
+	return 1
+`)
+
+// 多 key 剩余过期时间：返回最小的剩余时间，有 key 不再由持有者持有时返回 -3
+var multiPTTLScript = redis.NewScript(`
+	local min = -3
+	for i = 1, #KEYS do
+		if redis.call("get", KEYS[i]) ~= ARGV[1] then
+			return -3
+		end
+		local ttl = redis.call("pttl", KEYS[i])
+		if min == -3 or ttl < min then
+			min = ttl
+		end
+	end
+	return min
+`)
+
+// 公平锁清理等待队列：KEYS[2] 等待队列，KEYS[3] 等待者期限有序集合（score 为期限的毫秒时间戳）。
+// 从队首开始移除超过期限未再重试的等待者（进程崩溃或已放弃），遇到仍存活的等待者为止
+const fairReapQueue = `
+	local t = redis.call("time")
+	local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+	while true do
+		local head = redis.call("lindex", KEYS[2], 0)
+		if not head then
+			break
+		end
+		local deadline = redis.call("zscore", KEYS[3], head)
+		if deadline and tonumber(deadline) > now then
+			break
+		end
+		redis.call("lpop", KEYS[2])
+		redis.call("zrem", KEYS[3], head)
+	end
+`
+
+// 公平锁获取：KEYS[1] 锁，KEYS[2]、KEYS[3] 同上，KEYS[4] fencing token 计数器；
+// ARGV[1] 持有者标识，ARGV[2] 锁过期毫秒数，ARGV[3] 等待者期限毫秒数，ARGV[4] 为 1 时获取失败后排队。
+// 锁空闲且队列为空或自己是队首时获取；锁已由释放方移交给自己时认领；
+// 否则按 ARGV[4] 排到队尾（已在队列中时只延长期限）。获取成功时返回新的 fencing token，否则返回 0
+var fairAcquireScript = redis.NewScript(fairReapQueue + `
+	local owner = redis.call("get", KEYS[1])
+	if owner == ARGV[1] or (not owner and (redis.call("llen", KEYS[2]) == 0 or redis.call("lindex", KEYS[2], 0) == ARGV[1])) then
+		if not owner then
+			redis.call("lpop", KEYS[2])
+			redis.call("zrem", KEYS[3], ARGV[1])
+		end
+		redis.call("set", KEYS[1], ARGV[1], "px", ARGV[2])
+		return redis.call("incr", KEYS[4])
+	end
+	if ARGV[4] ~= "1" then
+		return 0
+	end
+	if not redis.call("zscore", KEYS[3], ARGV[1]) then
+		redis.call("rpush", KEYS[2], ARGV[1])
+	end
+	redis.call("zadd", KEYS[3], now + tonumber(ARGV[3]), ARGV[1])
+	redis.call("pexpire", KEYS[2], ARGV[3])
+	redis.call("pexpire", KEYS[3], ARGV[3])
+	return 0
+`)
+
+// 公平锁释放：KEYS 同获取；ARGV[1] 持有者标识，ARGV[2] 释放通知频道，ARGV[3] 等待者期限毫秒数。
+// 队列中有存活的等待者时把锁直接移交给队首（过期时间为等待者期限，由队首认领后再延长），
+// 否则删除锁；不是锁的持有者时返回 0
+var fairReleaseScript = redis.NewScript(fairReapQueue + `
+	if redis.call("get", KEYS[1]) ~= ARGV[1] then
+		return 0
+	end
+	local head = redis.call("lpop", KEYS[2])
+	if head then
+		redis.call("zrem", KEYS[3], head)
+		redis.call("set", KEYS[1], head, "px", ARGV[3])
+	else
+		redis.call("del", KEYS[1])
+	end
+	redis.call("publish", ARGV[2], KEYS[1])
+	return 1
+`)
+
+// 公平锁退出等待队列：等待者放弃获取时调用
+var fairDequeueScript = redis.NewScript(`
+	redis.call("lrem", KEYS[1], 0, ARGV[1])
+	return redis.call("zrem", KEYS[2], ARGV[1])
+`)
+
+// 强制释放：KEYS[1] 锁，KEYS[2] 读者集合，KEYS[3] 等待中的写者，KEYS[4] fencing token 计数器，
+// KEYS[5] 公平锁等待队列；ARGV[1] 释放通知频道。
+// 在同一个脚本中读出释放前的状态并删除，返回 {类型, 剩余毫秒数, 持有者, 读者, 读者剩余毫秒数, fencing token, 排队人数}；
+// KEYS[1] 不是锁时不做任何修改，只返回 {类型}
+var forceReleaseScript = redis.NewScript(`
+	local kind = redis.call("type", KEYS[1])["ok"]
+	local owners = {}
+	if kind == "string" then
+		owners = {redis.call("get", KEYS[1])}
+	elseif kind == "hash" then
+		owners = redis.call("hgetall", KEYS[1])
+	elseif kind == "zset" then
+		owners = redis.call("zrange", KEYS[1], 0, -1)
+	elseif kind ~= "none" then
+		return {kind}
+	end
+	local pttl = redis.call("pttl", KEYS[1])
+	local readers = redis.call("zrange", KEYS[2], 0, -1)
+	local readersPTTL = redis.call("pttl", KEYS[2])
+	local token = tonumber(redis.call("get", KEYS[4]) or "0")
+	local queued = redis.call("llen", KEYS[5])
+	redis.call("del", KEYS[1], KEYS[2], KEYS[3])
+	redis.call("publish", ARGV[1], KEYS[1])
+	return {kind, pttl, owners, readers, readersPTTL, token, queued}
+`)
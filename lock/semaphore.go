@@ -0,0 +1,131 @@
+package lock
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Semaphore 基于 Redis 有序集合的分布式计数信号量，多个进程共享 permits 个许可。
+// 有序集合的成员为持有者标识，score 为租约到期时间，持有者崩溃后租约到期自动回收。
+// 每个 Semaphore 对应一个持有者，同一持有者最多占用一个许可
+type Semaphore struct {
+	client  *Client
+	key     string
+	value   string
+	permits int
+	ttl     time.Duration
+	retry   RetryStrategy
+
+	wakeOnRelease bool
+	obs           *observation
+}
+
+// NewSemaphore 创建指定 key、许可数为 permits 的信号量，此时并不会去获取许可。
+// permits 不大于 0 时 panic
+func (c *Client) NewSemaphore(key string, permits int, opts *Options) *Semaphore {
+	if permits <= 0 {
+		panic("lock: 信号量的 permits 必须大于 0")
+	}
+	o := opts.withDefaults()
+	return &Semaphore{
+		client:        c,
+		key:           key,
+		value:         o.Value,
+		permits:       permits,
+		ttl:           o.TTL,
+		retry:         o.Retry,
+		wakeOnRelease: o.WakeOnRelease,
+		obs:           newObservation(o, key),
+	}
+}
+
+// Key 返回信号量的 key
+func (s *Semaphore) Key() string {
+	return s.key
+}
+
+// Value 返回持有者标识
+func (s *Semaphore) Value() string {
+	return s.value
+}
+
+// Acquire 阻塞获取许可，按重试策略重试直到获取成功或 ctx 结束
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	return s.obs.lock(ctx, func() error {
+		return s.acquire(ctx)
+	})
+}
+
+func (s *Semaphore) acquire(ctx context.Context) error {
+	var wake <-chan *redis.Message
+	if s.wakeOnRelease {
+		sub, ch, err := subscribeRelease(ctx, s.client.rdb, s.key)
+		if err != nil {
+			return err
+		}
+		defer sub.Close()
+		wake = ch
+	}
+	return retryLoop(ctx, s.retry, wake, func() (bool, error) {
+		return s.tryAcquire(ctx)
+	})
+}
+
+// TryAcquire 尝试获取一次许可，已持有时续租
+func (s *Semaphore) TryAcquire(ctx context.Context) (bool, error) {
+	return s.obs.tryLock(ctx, func() (bool, error) {
+		return s.tryAcquire(ctx)
+	})
+}
+
+func (s *Semaphore) tryAcquire(ctx context.Context) (bool, error) {
+	res, err := semAcquireScript.Run(ctx, s.client.rdb, []string{s.key},
+		s.value, s.ttl.Milliseconds(), s.permits).Int64()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+// Release 归还许可，不是持有者（或租约已过期被回收）时返回 ErrLockNotHeld
+func (s *Semaphore) Release(ctx context.Context) error {
+	return s.obs.unlock(ctx, s.release(ctx))
+}
+
+func (s *Semaphore) release(ctx context.Context) error {
+	res, err := semReleaseScript.Run(ctx, s.client.rdb, []string{s.key}, s.value, ReleaseChannel(s.key)).Int64()
+	if err != nil {
+		return err
+	}
+	if res != 1 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// Refresh 延长许可租约，ttl<=0 时使用创建时的租约时长
+func (s *Semaphore) Refresh(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = s.ttl
+	}
+	res, err := semRefreshScript.Run(ctx, s.client.rdb, []string{s.key}, s.value, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if res != 1 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// Reap 清理租约已过期的持有者，返回清理的数量
+func (s *Semaphore) Reap(ctx context.Context) (int64, error) {
+	return semReapScript.Run(ctx, s.client.rdb, []string{s.key}).Int64()
+}
+
+// Holders 返回当前占用许可的持有者数量（包含尚未清理的过期持有者）
+func (s *Semaphore) Holders(ctx context.Context) (int64, error) {
+	return s.client.rdb.ZCard(ctx, s.key).Result()
+}
@@ -0,0 +1,132 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSemaphorePermits(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, "test_semaphore")
+
+	holders := make([]*Semaphore, 4)
+	for i := range holders {
+		holders[i] = client.NewSemaphore("test_semaphore", 3, &Options{TTL: 5 * time.Second, Retry: NoRetry()})
+	}
+	for i := 0; i < 3; i++ {
+		if err := holders[i].Acquire(ctx); err != nil {
+			t.Fatalf("持有者%d 获取许可失败: %v", i, err)
+		}
+	}
+	if err := holders[3].Acquire(ctx); !errors.Is(err, ErrNotObtained) {
+		t.Errorf("许可用完后应获取失败，实际: %v", err)
+	}
+
+	if err := holders[0].Release(ctx); err != nil {
+		t.Fatalf("归还许可失败: %v", err)
+	}
+	if err := holders[0].Release(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("重复归还应返回 ErrLockNotHeld，实际: %v", err)
+	}
+	if err := holders[3].Acquire(ctx); err != nil {
+		t.Errorf("归还许可后应获取成功: %v", err)
+	}
+}
+
+func TestSemaphoreRejectsInvalidPermits(t *testing.T) {
+	client := newTestClient(t)
+	for _, permits := range []int{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("permits 为 %d 时应 panic", permits)
+				}
+			}()
+			client.NewSemaphore("test_semaphore", permits, nil)
+		}()
+	}
+}
+
+func TestSemaphoreReapsExpiredHolders(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, "test_semaphore_reap")
+
+	// 持有者崩溃后不归还许可
+	crashed := client.NewSemaphore("test_semaphore_reap", 1, &Options{TTL: 100 * time.Millisecond})
+	if err := crashed.Acquire(ctx); err != nil {
+		t.Fatalf("获取许可失败: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	if err := crashed.Refresh(ctx, 0); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("租约过期后续租应返回 ErrLockNotHeld，实际: %v", err)
+	}
+	n, err := crashed.Reap(ctx)
+	if err != nil {
+		t.Fatalf("清理过期持有者失败: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("期望清理1个过期持有者，实际%d个", n)
+	}
+
+	next := client.NewSemaphore("test_semaphore_reap", 1, &Options{TTL: 5 * time.Second, Retry: NoRetry()})
+	if err := next.Acquire(ctx); err != nil {
+		t.Errorf("过期持有者被清理后应获取成功: %v", err)
+	}
+}
+
+func TestSemaphoreLimitsConcurrency(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, "test_semaphore_concurrent")
+
+	maxWorkers := 3
+	var (
+		mu      sync.Mutex
+		running int
+		maxSeen int
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+
+			sem := client.NewSemaphore("test_semaphore_concurrent", maxWorkers, &Options{
+				TTL:           5 * time.Second,
+				Retry:         FixedBackoff(time.Second),
+				WakeOnRelease: true,
+			})
+			waitCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			defer cancel()
+			if err := sem.Acquire(waitCtx); err != nil {
+				t.Errorf("Worker %d 获取许可失败: %v", workerID, err)
+				return
+			}
+
+			mu.Lock()
+			running++
+			if running > maxSeen {
+				maxSeen = running
+			}
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+
+			if err := sem.Release(ctx); err != nil {
+				t.Errorf("Worker %d 归还许可失败: %v", workerID, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if maxSeen > maxWorkers {
+		t.Errorf("同时运行的 worker 不应超过%d个，实际有%d个", maxWorkers, maxSeen)
+	}
+}
@@ -0,0 +1,234 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultSQLTable 未指定表名时 SQLStore 使用的表
+const DefaultSQLTable = "distributed_locks"
+
+// SQLStore 基于数据库表的锁存储，每个锁 key 对应一行，通过带过期时间条件的
+// UPDATE/INSERT 实现互斥。语句使用 ? 占位符，适用于 MySQL 和 SQLite。
+//
+// 过期时间按客户端时钟计算并以毫秒时间戳保存，各实例之间的时钟偏差需要远小于 TTL。
+// 使用 go-sql-driver/mysql 时需要在 DSN 中设置 clientFoundRows=true，
+// 否则在同一毫秒内重复续期会因影响行数为 0 被误判为锁已丢失
+type SQLStore struct {
+	db    *sql.DB
+	table string
+
+	now func() time.Time
+}
+
+// NewSQLStore 基于数据库连接创建锁存储，table 为空时使用 DefaultSQLTable。
+// table 会直接拼接到 SQL 语句中，不能来自外部输入
+func NewSQLStore(db *sql.DB, table string) *SQLStore {
+	if table == "" {
+		table = DefaultSQLTable
+	}
+	return &SQLStore{db: db, table: table, now: time.Now}
+}
+
+// CreateTable 创建锁表（已存在时跳过），表结构见
+// database-design-table-test/case1_community_forum/schema.sql
+func (s *SQLStore) CreateTable(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+  lock_key VARCHAR(191) NOT NULL,
+  owner VARCHAR(64) NOT NULL,
+  expires_at BIGINT NOT NULL,
+  fence_token BIGINT NOT NULL,
+  PRIMARY KEY (lock_key)
+)`, s.table))
+	return err
+}
+
+// NewLock 创建指定 key 的锁，此时并不会去获取锁。Options.WakeOnRelease 不生效
+func (s *SQLStore) NewLock(key string, opts *Options) *SQLLock {
+	o := opts.withDefaults()
+	l := &SQLLock{
+		store: s,
+		key:   key,
+		value: o.Value,
+		ttl:   o.TTL,
+		retry: o.Retry,
+		obs:   newObservation(o, key),
+	}
+	l.watchdog = newWatchdog(o, l.Refresh, l.obs)
+	return l
+}
+
+// nowMillis 返回当前时间的毫秒时间戳
+func (s *SQLStore) nowMillis() int64 {
+	return s.now().UnixMilli()
+}
+
+// SQLLock 基于数据库表的锁，语义与 RedisLock 相同。
+// 释放锁时保留该行（owner 置空），使 fencing token 在 key 的整个生命周期内单调递增
+type SQLLock struct {
+	store *SQLStore
+	key   string
+	value string
+	ttl   time.Duration
+	retry RetryStrategy
+
+	watchdog *watchdog
+	obs      *observation
+	token    int64
+}
+
+var _ Locker = (*SQLLock)(nil)
+
+// Key 返回锁的 key
+func (l *SQLLock) Key() string {
+	return l.key
+}
+
+// Value 返回锁持有者标识
+func (l *SQLLock) Value() string {
+	return l.value
+}
+
+// Token 返回最近一次获取锁得到的 fencing token，尚未获取过锁时返回 0
+func (l *SQLLock) Token() int64 {
+	return atomic.LoadInt64(&l.token)
+}
+
+// Lock 阻塞获取锁，按重试策略重试直到获取成功或 ctx 结束
+func (l *SQLLock) Lock(ctx context.Context) error {
+	return l.obs.lock(ctx, func() error {
+		return l.lock(ctx)
+	})
+}
+
+func (l *SQLLock) lock(ctx context.Context) error {
+	return retryLoop(ctx, l.retry, nil, func() (bool, error) {
+		return l.tryLock(ctx)
+	})
+}
+
+// TryLock 尝试获取一次锁，获取成功时同时分配新的 fencing token
+func (l *SQLLock) TryLock(ctx context.Context) (bool, error) {
+	return l.obs.tryLock(ctx, func() (bool, error) {
+		return l.tryLock(ctx)
+	})
+}
+
+func (l *SQLLock) tryLock(ctx context.Context) (bool, error) {
+	s := l.store
+	now := s.nowMillis()
+	expiresAt := now + l.ttl.Milliseconds()
+
+	// 先接管已过期或已释放的行，没有该行时再插入
+	res, err := s.db.ExecContext(ctx, fmt.Sprintf(
+		"UPDATE %s SET owner = ?, expires_at = ?, fence_token = fence_token + 1 WHERE lock_key = ? AND expires_at <= ?",
+		s.table), l.value, expiresAt, l.key, now)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return false, err
+	} else if n == 0 {
+		_, insertErr := s.db.ExecContext(ctx, fmt.Sprintf(
+			"INSERT INTO %s (lock_key, owner, expires_at, fence_token) VALUES (?, ?, ?, 1)",
+			s.table), l.key, l.value, expiresAt)
+		if insertErr != nil {
+			// 各数据库的主键冲突错误不同，插入失败后通过查询该行判断是否被其他持有者占用
+			var exists int
+			err := s.db.QueryRowContext(ctx, fmt.Sprintf(
+				"SELECT 1 FROM %s WHERE lock_key = ?", s.table), l.key).Scan(&exists)
+			if errors.Is(err, sql.ErrNoRows) {
+				return false, insertErr
+			}
+			return false, err
+		}
+	}
+
+	var token int64
+	err = s.db.QueryRowContext(ctx, fmt.Sprintf(
+		"SELECT fence_token FROM %s WHERE lock_key = ? AND owner = ?", s.table), l.key, l.value).Scan(&token)
+	if errors.Is(err, sql.ErrNoRows) {
+		// 刚获取的锁在读取 token 前已过期并被其他持有者获取
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	atomic.StoreInt64(&l.token, token)
+	l.watchdog.start(ctx)
+	return true, nil
+}
+
+// Unlock 释放锁
+func (l *SQLLock) Unlock(ctx context.Context) error {
+	return l.obs.unlock(ctx, l.unlock(ctx))
+}
+
+func (l *SQLLock) unlock(ctx context.Context) error {
+	l.watchdog.stop()
+
+	s := l.store
+	res, err := s.db.ExecContext(ctx, fmt.Sprintf(
+		"UPDATE %s SET owner = '', expires_at = 0 WHERE lock_key = ? AND owner = ? AND expires_at > ?",
+		s.table), l.key, l.value, s.nowMillis())
+	if err != nil {
+		return err
+	}
+	return expectOneRow(res)
+}
+
+// Refresh 延长锁的过期时间
+func (l *SQLLock) Refresh(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = l.ttl
+	}
+
+	s := l.store
+	now := s.nowMillis()
+	res, err := s.db.ExecContext(ctx, fmt.Sprintf(
+		"UPDATE %s SET expires_at = ? WHERE lock_key = ? AND owner = ? AND expires_at > ?",
+		s.table), now+ttl.Milliseconds(), l.key, l.value, now)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(res)
+}
+
+// TTL 返回锁的剩余过期时间
+func (l *SQLLock) TTL(ctx context.Context) (time.Duration, error) {
+	s := l.store
+	var expiresAt int64
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf(
+		"SELECT expires_at FROM %s WHERE lock_key = ? AND owner = ?", s.table), l.key, l.value).Scan(&expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if ttl := expiresAt - s.nowMillis(); ttl > 0 {
+		return time.Duration(ttl) * time.Millisecond, nil
+	}
+	return 0, nil
+}
+
+// Lost 返回锁丢失信号：看门狗续期失败时关闭，未开启 AutoRenew 时永远不会关闭
+func (l *SQLLock) Lost() <-chan struct{} {
+	return l.watchdog.Lost()
+}
+
+// expectOneRow 条件更新没有命中时说明锁已不再由当前持有者持有
+func expectOneRow(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
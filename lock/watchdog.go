@@ -0,0 +1,37 @@
+package lock
+
+import (
+	"context"
+	"time"
+
+	"goRedisLock/internal/renew"
+)
+
+// watchdog 看门狗：持有锁期间定期续期，续期失败时发出锁丢失信号。
+// 续期循环见 internal/renew，这里按 Options.AutoRenew 决定是否启动
+type watchdog struct {
+	enabled bool
+	*renew.Watchdog
+}
+
+// newWatchdog 创建看门狗，续期失败时通过 obs 上报
+func newWatchdog(opts *Options, refresh func(ctx context.Context, ttl time.Duration) error, obs *observation) *watchdog {
+	w := &watchdog{
+		enabled:  opts.AutoRenew,
+		Watchdog: renew.New(opts.TTL, opts.RenewInterval, refresh, ErrLockNotHeld),
+	}
+	w.OnRenewError = obs.renewalFailed
+	return w
+}
+
+// start 启动看门狗，ctx 取消时停止续期；未开启 AutoRenew 时不做任何事
+func (w *watchdog) start(ctx context.Context) {
+	if w.enabled {
+		w.Start(ctx)
+	}
+}
+
+// stop 停止看门狗并等待其退出
+func (w *watchdog) stop() {
+	w.Stop()
+}
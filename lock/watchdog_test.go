@@ -0,0 +1,132 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"goRedisLock/internal/redistest"
+)
+
+func TestWatchdogKeepsLockAlive(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, "test_watchdog_lock")
+
+	locker := client.NewLock("test_watchdog_lock", &Options{
+		TTL:           300 * time.Millisecond,
+		AutoRenew:     true,
+		RenewInterval: 50 * time.Millisecond,
+	})
+	if err := locker.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+
+	// 持有时间超过 TTL，看门狗应持续续期
+	time.Sleep(time.Second)
+	ttl, err := locker.TTL(ctx)
+	if err != nil {
+		t.Fatalf("查询 TTL 失败: %v", err)
+	}
+	if ttl <= 0 {
+		t.Fatal("看门狗未能续期，锁已过期")
+	}
+
+	if err := locker.Unlock(ctx); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+	select {
+	case <-locker.Lost():
+		t.Error("正常释放后不应触发锁丢失信号")
+	default:
+	}
+}
+
+func TestWatchdogSignalsLostLease(t *testing.T) {
+	ctx := context.Background()
+	server := redistest.New(t, "test_watchdog_lost_lock")
+	client := NewClient(server.Client)
+
+	locker := client.NewLock("test_watchdog_lost_lock", &Options{
+		TTL:           time.Second,
+		AutoRenew:     true,
+		RenewInterval: 50 * time.Millisecond,
+	})
+	if err := locker.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+
+	// 模拟锁被其他进程抢走
+	server.Client.Set(ctx, "test_watchdog_lost_lock", "other_owner", time.Second)
+
+	select {
+	case <-locker.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("续期失败后未收到锁丢失信号")
+	}
+}
+
+func TestWatchdogStopsOnContextCancel(t *testing.T) {
+	server := redistest.New(t, "test_watchdog_cancel_lock", FenceKey("test_watchdog_cancel_lock"))
+	client := NewClient(server.Client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	locker := client.NewLock("test_watchdog_cancel_lock", &Options{
+		TTL:           300 * time.Millisecond,
+		AutoRenew:     true,
+		RenewInterval: 50 * time.Millisecond,
+	})
+	if err := locker.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+	cancel()
+
+	// ctx 取消后看门狗停止续期，锁按 TTL 过期
+	time.Sleep(100 * time.Millisecond)
+	server.FastForward(time.Second)
+	other := client.NewLock("test_watchdog_cancel_lock", &Options{Retry: NoRetry()})
+	if ok, err := other.TryLock(context.Background()); err != nil || !ok {
+		t.Fatalf("ctx 取消后锁应过期: %v, %v", ok, err)
+	}
+	other.Unlock(context.Background())
+
+	// Unlock 需要等待看门狗退出，ctx 取消后不应阻塞
+	if err := locker.Unlock(context.Background()); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("锁过期后释放应返回 ErrLockNotHeld，实际: %v", err)
+	}
+}
+
+// 获取锁时传入 context.WithoutCancel，看门狗在原 ctx 结束后仍继续续期，直到 Unlock
+func TestWatchdogDetachedFromAcquireContext(t *testing.T) {
+	server := redistest.New(t, "test_watchdog_detached_lock", FenceKey("test_watchdog_detached_lock"))
+	client := NewClient(server.Client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	locker := client.NewLock("test_watchdog_detached_lock", &Options{
+		TTL:           300 * time.Millisecond,
+		AutoRenew:     true,
+		RenewInterval: 50 * time.Millisecond,
+	})
+	if err := locker.Lock(context.WithoutCancel(ctx)); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+	cancel()
+
+	// 累计快进远超 TTL，只有持续续期锁才不会过期
+	for i := 0; i < 10; i++ {
+		time.Sleep(100 * time.Millisecond)
+		server.FastForward(100 * time.Millisecond)
+		select {
+		case <-locker.Lost():
+			t.Fatalf("第%d次快进后锁丢失，ctx 取消后看门狗不应停止", i+1)
+		default:
+		}
+	}
+	if ttl, err := locker.TTL(context.Background()); err != nil || ttl <= 0 {
+		t.Fatalf("ctx 取消后锁未被续期: %v, %v", ttl, err)
+	}
+
+	if err := locker.Unlock(context.Background()); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package lock
+
+import (
+	"context"
+	"errors"
+)
+
+// WithLock 持有 key 上的锁执行 fn：阻塞获取锁（按 opts 的重试策略），持有期间由看门狗自动续期，
+// 锁丢失时取消传给 fn 的 ctx（context.Cause 为 ErrLockNotHeld），fn 返回或 panic 后都会释放锁。
+//
+// 返回 fn 的错误与释放过程中的错误的组合：获取失败时返回 ErrNotObtained，
+// 执行期间锁丢失时包含 ErrLockNotHeld。opts 中的 AutoRenew 总是开启
+func (c *Client) WithLock(ctx context.Context, key string, opts *Options, fn func(ctx context.Context) error) (err error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	o.AutoRenew = true
+
+	l := c.NewLock(key, &o)
+	if err := l.Lock(ctx); err != nil {
+		return err
+	}
+
+	inner, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	lost := l.Lost()
+	finished := make(chan struct{})
+	go func() {
+		select {
+		case <-lost:
+			cancel(ErrLockNotHeld)
+		case <-finished:
+		}
+	}()
+
+	// fn panic 时同样释放锁并停止看门狗，panic 随后继续向上传递
+	defer func() {
+		close(finished)
+
+		var lostErr error
+		select {
+		case <-lost:
+			lostErr = ErrLockNotHeld
+		default:
+		}
+
+		// 调用方的 ctx 已取消时仍要释放锁
+		releaseErr := l.Unlock(context.WithoutCancel(ctx))
+		if errors.Is(releaseErr, ErrLockNotHeld) {
+			releaseErr, lostErr = nil, ErrLockNotHeld
+		}
+		err = errors.Join(err, lostErr, releaseErr)
+	}()
+
+	return fn(inner)
+}
@@ -0,0 +1,101 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"goRedisLock/internal/redistest"
+)
+
+func TestWithLock(t *testing.T) {
+	ctx := context.Background()
+	server := redistest.New(t, "test_with_lock")
+	client := NewClient(server.Client)
+
+	errBusiness := errors.New("业务失败")
+	err := client.WithLock(ctx, "test_with_lock", nil, func(ctx context.Context) error {
+		// 执行期间锁被持有
+		if ok, _ := client.NewLock("test_with_lock", nil).TryLock(ctx); ok {
+			t.Error("执行期间其他持有者不应获取成功")
+		}
+		return errBusiness
+	})
+	if !errors.Is(err, errBusiness) {
+		t.Errorf("应返回业务错误，实际: %v", err)
+	}
+	if server.Client.Exists(ctx, "test_with_lock").Val() != 0 {
+		t.Error("业务失败后也应释放锁")
+	}
+
+	// 锁被占用时获取失败，不执行 fn
+	holder := client.NewLock("test_with_lock", nil)
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+	called := false
+	err = client.WithLock(ctx, "test_with_lock", &Options{Retry: NoRetry()}, func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, ErrNotObtained) || called {
+		t.Errorf("锁被占用时应返回 ErrNotObtained 且不执行 fn，实际: %v, %v", err, called)
+	}
+}
+
+func TestWithLockReleasesOnPanic(t *testing.T) {
+	ctx := context.Background()
+	server := redistest.New(t, "test_with_lock_panic", FenceKey("test_with_lock_panic"))
+	client := NewClient(server.Client)
+
+	func() {
+		defer func() {
+			if r := recover(); r != "业务 panic" {
+				t.Errorf("fn 的 panic 应继续向上传递，实际: %v", r)
+			}
+		}()
+		opts := &Options{TTL: 300 * time.Millisecond, RenewInterval: 50 * time.Millisecond}
+		client.WithLock(ctx, "test_with_lock_panic", opts, func(ctx context.Context) error {
+			panic("业务 panic")
+		})
+	}()
+
+	if server.Client.Exists(ctx, "test_with_lock_panic").Val() != 0 {
+		t.Error("fn panic 后应释放锁")
+	}
+	next := client.NewLock("test_with_lock_panic", &Options{Retry: NoRetry()})
+	if err := next.Lock(ctx); err != nil {
+		t.Fatalf("fn panic 后应能重新获取锁: %v", err)
+	}
+	next.Unlock(ctx)
+}
+
+func TestWithLockCancelsOnLost(t *testing.T) {
+	ctx := context.Background()
+	server := redistest.New(t, "test_with_lock_lost")
+	client := NewClient(server.Client)
+
+	opts := &Options{TTL: 3 * time.Second, RenewInterval: 20 * time.Millisecond}
+	err := client.WithLock(ctx, "test_with_lock_lost", opts, func(ctx context.Context) error {
+		// 模拟锁被运维强制释放后由其他进程获取
+		server.Client.Set(ctx, "test_with_lock_lost", "other", time.Minute)
+
+		select {
+		case <-ctx.Done():
+			if !errors.Is(context.Cause(ctx), ErrLockNotHeld) {
+				t.Errorf("取消原因应为 ErrLockNotHeld，实际: %v", context.Cause(ctx))
+			}
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+			t.Error("锁丢失后应取消 ctx")
+			return nil
+		}
+	})
+	if !errors.Is(err, ErrLockNotHeld) || !errors.Is(err, context.Canceled) {
+		t.Errorf("应同时返回业务错误和 ErrLockNotHeld，实际: %v", err)
+	}
+	if v := server.Client.Get(ctx, "test_with_lock_lost").Val(); v != "other" {
+		t.Errorf("不应释放其他进程持有的锁，实际: %q", v)
+	}
+}
@@ -2,37 +2,25 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
 	"time"
 
-	"github.com/go-redis/redis/v8"
+	"goRedisLock/internal/redistest"
+	"goRedisLock/lock"
 )
 
 // 测试分布式锁的并发互斥性
 func TestDistributedLockConcurrency(t *testing.T) {
-	// 连接Redis
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379",
-		Password: "",
-		DB:       0,
-	})
+	// 连接Redis（测试结束时清理测试数据）：默认使用进程内的 miniredis，设置 TEST_REDIS_ADDR 时连接真实 Redis
+	server := redistest.New(t, "test_concurrent_lock", lock.FenceKey("test_concurrent_lock"))
+	rdb := server.Client
+	locks := lock.NewClient(rdb)
 
 	ctx := context.Background()
 
-	// 测试连接
-	_, err := rdb.Ping(ctx).Result()
-	if err != nil {
-		t.Fatalf("Redis连接失败: %v", err)
-	}
-
-	// 清理测试数据
-	defer func() {
-		rdb.Del(ctx, "test_concurrent_lock")
-		rdb.Close()
-	}()
-
 	// 测试参数
 	lockKey := "test_concurrent_lock"
 	numGoroutines := 10
@@ -47,10 +35,13 @@ func TestDistributedLockConcurrency(t *testing.T) {
 		go func(goroutineID int) {
 			defer wg.Done()
 
-			lockValue := fmt.Sprintf("goroutine_%d_%d", goroutineID, time.Now().UnixNano())
+			locker := locks.NewLock(lockKey, &lock.Options{
+				TTL:   lockDuration,
+				Value: fmt.Sprintf("goroutine_%d_%d", goroutineID, time.Now().UnixNano()),
+			})
 
 			// 尝试获取锁
-			acquired, err := acquireTestLock(ctx, rdb, lockKey, lockValue, lockDuration)
+			acquired, err := locker.TryLock(ctx)
 			if err != nil {
 				t.Errorf("Goroutine %d 获取锁失败: %v", goroutineID, err)
 				return
@@ -68,13 +59,13 @@ func TestDistributedLockConcurrency(t *testing.T) {
 				time.Sleep(100 * time.Millisecond)
 
 				// 释放锁
-				released, err := releaseTestLock(ctx, rdb, lockKey, lockValue)
-				if err != nil {
+				err := locker.Unlock(ctx)
+				if errors.Is(err, lock.ErrLockNotHeld) {
+					t.Logf("Goroutine %d 锁已过期或被其他进程获取", goroutineID)
+				} else if err != nil {
 					t.Errorf("Goroutine %d 释放锁失败: %v", goroutineID, err)
-				} else if released {
-					t.Logf("Goroutine %d 成功释放锁", goroutineID)
 				} else {
-					t.Logf("Goroutine %d 锁已过期或被其他进程获取", goroutineID)
+					t.Logf("Goroutine %d 成功释放锁", goroutineID)
 				}
 			} else {
 				t.Logf("Goroutine %d 获取锁失败，锁已被其他进程持有", goroutineID)
@@ -95,33 +86,21 @@ func TestDistributedLockConcurrency(t *testing.T) {
 
 // 测试分布式锁的安全性
 func TestDistributedLockSafety(t *testing.T) {
-	// 连接Redis
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379",
-		Password: "",
-		DB:       0,
-	})
+	// 连接Redis（测试结束时清理测试数据）：默认使用进程内的 miniredis，设置 TEST_REDIS_ADDR 时连接真实 Redis
+	server := redistest.New(t, "test_safety_lock", lock.FenceKey("test_safety_lock"))
+	rdb := server.Client
+	locks := lock.NewClient(rdb)
 
 	ctx := context.Background()
 
-	// 测试连接
-	_, err := rdb.Ping(ctx).Result()
-	if err != nil {
-		t.Fatalf("Redis连接失败: %v", err)
-	}
-
-	// 清理测试数据
-	defer func() {
-		rdb.Del(ctx, "test_safety_lock")
-		rdb.Close()
-	}()
-
 	lockKey := "test_safety_lock"
-	lockValue := fmt.Sprintf("test_%d", time.Now().UnixNano())
-	lockDuration := 10 * time.Second
+	locker := locks.NewLock(lockKey, &lock.Options{
+		TTL:   10 * time.Second,
+		Value: fmt.Sprintf("test_%d", time.Now().UnixNano()),
+	})
 
 	// 获取锁
-	acquired, err := acquireTestLock(ctx, rdb, lockKey, lockValue, lockDuration)
+	acquired, err := locker.TryLock(ctx)
 	if err != nil {
 		t.Fatalf("获取锁失败: %v", err)
 	}
@@ -131,24 +110,19 @@ func TestDistributedLockSafety(t *testing.T) {
 	}
 
 	// 测试用错误值释放锁
-	wrongValue := "wrong_value"
-	released, err := releaseTestLock(ctx, rdb, lockKey, wrongValue)
-	if err != nil {
+	wrongLocker := locks.NewLock(lockKey, &lock.Options{Value: "wrong_value"})
+	err = wrongLocker.Unlock(ctx)
+	if errors.Is(err, lock.ErrLockNotHeld) {
+		t.Log("用错误值释放锁失败，这是正确的")
+	} else if err != nil {
 		t.Errorf("释放锁失败: %v", err)
-	}
-	if released {
-		t.Error("用错误值释放锁成功，这不应该发生")
 	} else {
-		t.Log("用错误值释放锁失败，这是正确的")
+		t.Error("用错误值释放锁成功，这不应该发生")
 	}
 
 	// 测试用正确值释放锁
-	released, err = releaseTestLock(ctx, rdb, lockKey, lockValue)
-	if err != nil {
-		t.Errorf("释放锁失败: %v", err)
-	}
-	if !released {
-		t.Error("用正确值释放锁失败")
+	if err := locker.Unlock(ctx); err != nil {
+		t.Errorf("用正确值释放锁失败: %v", err)
 	} else {
 		t.Log("用正确值释放锁成功")
 	}
@@ -156,33 +130,22 @@ func TestDistributedLockSafety(t *testing.T) {
 
 // 测试分布式锁的超时机制
 func TestDistributedLockTimeout(t *testing.T) {
-	// 连接Redis
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379",
-		Password: "",
-		DB:       0,
-	})
+	// 连接Redis（测试结束时清理测试数据）：默认使用进程内的 miniredis，设置 TEST_REDIS_ADDR 时连接真实 Redis
+	server := redistest.New(t, "test_timeout_lock", lock.FenceKey("test_timeout_lock"))
+	rdb := server.Client
+	locks := lock.NewClient(rdb)
 
 	ctx := context.Background()
 
-	// 测试连接
-	_, err := rdb.Ping(ctx).Result()
-	if err != nil {
-		t.Fatalf("Redis连接失败: %v", err)
-	}
-
-	// 清理测试数据
-	defer func() {
-		rdb.Del(ctx, "test_timeout_lock")
-		rdb.Close()
-	}()
-
 	lockKey := "test_timeout_lock"
-	lockValue := fmt.Sprintf("test_%d", time.Now().UnixNano())
 	lockDuration := 2 * time.Second // 短超时时间
+	locker := locks.NewLock(lockKey, &lock.Options{
+		TTL:   lockDuration,
+		Value: fmt.Sprintf("test_%d", time.Now().UnixNano()),
+	})
 
 	// 获取锁
-	acquired, err := acquireTestLock(ctx, rdb, lockKey, lockValue, lockDuration)
+	acquired, err := locker.TryLock(ctx)
 	if err != nil {
 		t.Fatalf("获取锁失败: %v", err)
 	}
@@ -193,50 +156,40 @@ func TestDistributedLockTimeout(t *testing.T) {
 
 	t.Logf("获取锁成功，等待 %v 让锁自动过期", lockDuration+1*time.Second)
 
-	// 等待锁过期
-	time.Sleep(lockDuration + 1*time.Second)
+	// 等待锁过期（miniredis 下直接快进时间）
+	server.FastForward(lockDuration + 1*time.Second)
 
 	// 尝试释放已过期的锁
-	released, err := releaseTestLock(ctx, rdb, lockKey, lockValue)
-	if err != nil {
+	err = locker.Unlock(ctx)
+	if errors.Is(err, lock.ErrLockNotHeld) {
+		t.Log("释放已过期锁失败（锁已自动过期）")
+	} else if err != nil {
 		t.Errorf("释放锁失败: %v", err)
-	}
-	if released {
-		t.Log("释放已过期锁成功（锁可能被其他进程重新获取）")
 	} else {
-		t.Log("释放已过期锁失败（锁已自动过期）")
+		t.Error("释放已过期锁成功，锁未按时过期")
 	}
 }
 
 // 测试分布式锁的续期机制
 func TestDistributedLockRenewal(t *testing.T) {
-	// 连接Redis
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379",
-		Password: "",
-		DB:       0,
-	})
+	// 连接Redis（测试结束时清理测试数据）：默认使用进程内的 miniredis，设置 TEST_REDIS_ADDR 时连接真实 Redis
+	server := redistest.New(t, "test_renewal_lock", lock.FenceKey("test_renewal_lock"))
+	rdb := server.Client
+	locks := lock.NewClient(rdb)
 
 	ctx := context.Background()
 
-	// 测试连接
-	_, err := rdb.Ping(ctx).Result()
-	if err != nil {
-		t.Fatalf("Redis连接失败: %v", err)
-	}
-
-	// 清理测试数据
-	defer func() {
-		rdb.Del(ctx, "test_renewal_lock")
-		rdb.Close()
-	}()
-
 	lockKey := "test_renewal_lock"
 	lockValue := fmt.Sprintf("test_%d", time.Now().UnixNano())
-	lockDuration := 3 * time.Second
+	locker := locks.NewLock(lockKey, &lock.Options{
+		TTL:           300 * time.Millisecond,
+		Value:         lockValue,
+		AutoRenew:     true,
+		RenewInterval: 50 * time.Millisecond,
+	})
 
 	// 获取锁
-	acquired, err := acquireTestLock(ctx, rdb, lockKey, lockValue, lockDuration)
+	acquired, err := locker.TryLock(ctx)
 	if err != nil {
 		t.Fatalf("获取锁失败: %v", err)
 	}
@@ -247,58 +200,44 @@ func TestDistributedLockRenewal(t *testing.T) {
 
 	t.Log("获取锁成功，开始测试续期机制")
 
-	// 模拟长时间业务处理
+	// 模拟长时间业务处理，业务时间超过锁的过期时间，由看门狗自动续期
+	// （miniredis 下 key 只随快进过期，每轮先等看门狗续期再快进时间）
 	for i := 0; i < 5; i++ {
-		time.Sleep(1 * time.Second)
+		select {
+		case <-locker.Lost():
+			t.Fatalf("第%d次检查：续期失败，锁已丢失", i+1)
+		case <-time.After(100 * time.Millisecond):
+		}
+		server.FastForward(100 * time.Millisecond)
 
 		// 检查锁是否仍然有效
 		val, err := rdb.Get(ctx, lockKey).Result()
 		if err != nil {
-			t.Logf("锁已过期或被删除: %v", err)
-			break
+			t.Fatalf("锁已过期或被删除: %v", err)
 		}
-		if val == lockValue {
-			t.Logf("第%d秒：锁仍然有效", i+1)
-		} else {
-			t.Logf("第%d秒：锁已被其他进程获取", i+1)
-			break
+		if val != lockValue {
+			t.Fatalf("第%d次检查：锁已被其他进程获取", i+1)
 		}
+		t.Logf("第%d次检查：锁仍然有效", i+1)
 	}
 
 	// 释放锁
-	released, err := releaseTestLock(ctx, rdb, lockKey, lockValue)
-	if err != nil {
+	if err := locker.Unlock(ctx); err != nil {
 		t.Errorf("释放锁失败: %v", err)
-	} else if released {
-		t.Log("成功释放锁")
 	} else {
-		t.Log("锁已过期或被其他进程获取")
+		t.Log("成功释放锁")
 	}
 }
 
 // 测试分布式锁的竞争条件
 func TestDistributedLockRaceCondition(t *testing.T) {
-	// 连接Redis
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379",
-		Password: "",
-		DB:       0,
-	})
+	// 连接Redis（测试结束时清理测试数据）：默认使用进程内的 miniredis，设置 TEST_REDIS_ADDR 时连接真实 Redis
+	server := redistest.New(t, "test_race_lock", lock.FenceKey("test_race_lock"))
+	rdb := server.Client
+	locks := lock.NewClient(rdb)
 
 	ctx := context.Background()
 
-	// 测试连接
-	_, err := rdb.Ping(ctx).Result()
-	if err != nil {
-		t.Fatalf("Redis连接失败: %v", err)
-	}
-
-	// 清理测试数据
-	defer func() {
-		rdb.Del(ctx, "test_race_lock")
-		rdb.Close()
-	}()
-
 	lockKey := "test_race_lock"
 	numGoroutines := 20
 	lockDuration := 3 * time.Second
@@ -312,10 +251,13 @@ func TestDistributedLockRaceCondition(t *testing.T) {
 		go func(goroutineID int) {
 			defer wg.Done()
 
-			lockValue := fmt.Sprintf("race_%d_%d", goroutineID, time.Now().UnixNano())
+			locker := locks.NewLock(lockKey, &lock.Options{
+				TTL:   lockDuration,
+				Value: fmt.Sprintf("race_%d_%d", goroutineID, time.Now().UnixNano()),
+			})
 
 			// 尝试获取锁
-			acquired, err := acquireTestLock(ctx, rdb, lockKey, lockValue, lockDuration)
+			acquired, err := locker.TryLock(ctx)
 			if err != nil {
 				t.Errorf("Goroutine %d 获取锁失败: %v", goroutineID, err)
 				return
@@ -332,10 +274,9 @@ func TestDistributedLockRaceCondition(t *testing.T) {
 				time.Sleep(50 * time.Millisecond)
 
 				// 释放锁
-				released, err := releaseTestLock(ctx, rdb, lockKey, lockValue)
-				if err != nil {
+				if err := locker.Unlock(ctx); err != nil {
 					t.Errorf("Goroutine %d 释放锁失败: %v", goroutineID, err)
-				} else if released {
+				} else {
 					t.Logf("Goroutine %d 成功释放锁", goroutineID)
 				}
 			} else {
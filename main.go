@@ -2,26 +2,36 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"os"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+
+	"goRedisLock/config"
+	"goRedisLock/lock"
 )
 
 func main() {
+	// 加载Redis连接配置：配置文件、环境变量（REDIS_ 前缀）或命令行参数
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal("加载Redis配置失败: ", err)
+	}
+
 	// 连接Redis
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379", // Redis地址
-		Password: "",               // 密码
-		DB:       0,                // 数据库
-	})
+	rdb, err := cfg.NewClient()
+	if err != nil {
+		log.Fatal("创建Redis客户端失败: ", err)
+	}
 
 	ctx := context.Background()
 
 	// 测试连接，带重试机制
 	var pong string
-	var err error
 	maxRetries := 3
 
 	for i := 0; i < maxRetries; i++ {
@@ -36,7 +46,7 @@ func main() {
 	}
 
 	if err != nil {
-		log.Fatal("Redis连接失败，请确保Redis服务在6479端口运行:", err)
+		log.Fatalf("Redis连接失败，请确保Redis服务在 %s 运行: %v", cfg.Addr(), err)
 	}
 	fmt.Println("Redis连接成功:", pong)
 
@@ -51,7 +61,7 @@ func main() {
 }
 
 // 基本Redis操作
-func basicOperations(ctx context.Context, rdb *redis.Client) {
+func basicOperations(ctx context.Context, rdb redis.UniversalClient) {
 	fmt.Println("\n=== 基本Redis操作 ===")
 
 	// SET操作
@@ -96,64 +106,34 @@ func basicOperations(ctx context.Context, rdb *redis.Client) {
 }
 
 // 分布式锁示例
-func distributedLockExample(ctx context.Context, rdb *redis.Client) {
+func distributedLockExample(ctx context.Context, rdb redis.UniversalClient) {
 	fmt.Println("\n=== 分布式锁示例 ===")
 
-	lockKey := "mylock"
-	lockValue := "lock_value_123"
-	lockDuration := 30 * time.Second
-
-	// 获取锁
-	acquired, err := acquireLock(ctx, rdb, lockKey, lockValue, lockDuration)
-	if err != nil {
-		log.Printf("获取锁失败: %v", err)
-		return
-	}
-
-	if acquired {
+	// 只尝试一次获取锁，持有期间自动续期，业务结束后自动释放
+	err := lock.NewClient(rdb).WithLock(ctx, "mylock", &lock.Options{
+		TTL:   30 * time.Second,
+		Value: "lock_value_123",
+		Retry: lock.NoRetry(),
+	}, func(ctx context.Context) error {
 		fmt.Println("成功获取分布式锁")
 
-		// 模拟业务处理
-		time.Sleep(2 * time.Second)
-
-		// 释放锁
-		released, err := releaseLock(ctx, rdb, lockKey, lockValue)
-		if err != nil {
-			log.Printf("释放锁失败: %v", err)
-		} else if released {
-			fmt.Println("成功释放分布式锁")
-		} else {
-			fmt.Println("锁已过期或被其他进程获取")
+		// 模拟业务处理，锁丢失时 ctx 会被取消
+		select {
+		case <-time.After(2 * time.Second):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
 		}
-	} else {
-		fmt.Println("获取锁失败，锁已被其他进程持有")
-	}
-}
-
-// 获取分布式锁
-func acquireLock(ctx context.Context, rdb *redis.Client, key, value string, duration time.Duration) (bool, error) {
-	result, err := rdb.SetNX(ctx, key, value, duration).Result()
-	if err != nil {
-		return false, err
-	}
-	return result, nil
-}
+	})
 
-// 释放分布式锁
-func releaseLock(ctx context.Context, rdb *redis.Client, key, value string) (bool, error) {
-	// 使用Lua脚本确保原子性：只有锁的持有者才能释放锁
-	script := `
-		if redis.call("get", KEYS[1]) == ARGV[1] then
-			return redis.call("del", KEYS[1])
-		else
-			return 0
-		end
-	`
-
-	result, err := rdb.Eval(ctx, script, []string{key}, value).Result()
-	if err != nil {
-		return false, err
+	switch {
+	case errors.Is(err, lock.ErrNotObtained):
+		fmt.Println("获取锁失败，锁已被其他进程持有")
+	case errors.Is(err, lock.ErrLockNotHeld):
+		fmt.Println("锁已过期或被其他进程获取")
+	case err != nil:
+		log.Printf("分布式锁示例失败: %v", err)
+	default:
+		fmt.Println("成功释放分布式锁")
 	}
-
-	return result.(int64) == 1, nil
 }
@@ -0,0 +1,37 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+)
+
+// Transport 返回限流的 http.RoundTripper：每个请求（包括重试）发出前先通过 l.Wait，
+// 等待期间请求的 ctx 结束时返回 ctx 的错误。next 为 nil 时使用 http.DefaultTransport
+func Transport(l *Limiter, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if err := l.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+		return next.RoundTrip(req)
+	})
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Wrap 返回限流的任务函数，用于 worker pool：每次调用前先通过 l.Wait，
+// 多个 worker、多个实例共享 l 的配额
+func Wrap(l *Limiter, fn func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if err := l.Wait(ctx); err != nil {
+			return err
+		}
+		return fn(ctx)
+	}
+}
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"goRedisLock/internal/redistest"
+)
+
+func TestTransport(t *testing.T) {
+	var hits int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer backend.Close()
+
+	limiter := NewClient(redistest.New(t, "test_ratelimit_transport").Client).NewLimiter("test_ratelimit_transport", &Options{Rate: 3, Period: time.Minute})
+	client := &http.Client{Transport: Transport(limiter, nil)}
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(backend.URL)
+		if err != nil {
+			t.Fatalf("第%d个请求失败: %v", i+1, err)
+		}
+		resp.Body.Close()
+	}
+
+	// 配额用完后请求在发出前被拦住
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, backend.URL, nil)
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("期望 context.DeadlineExceeded，实际: %v", err)
+	}
+	if hits != 3 {
+		t.Errorf("期望后端收到3个请求，实际: %d", hits)
+	}
+}
+
+// worker pool 中的多个 worker 共享配额
+func TestWrapWorkerPool(t *testing.T) {
+	limiter := NewClient(redistest.New(t, "test_ratelimit_worker_pool").Client).NewLimiter("test_ratelimit_worker_pool", &Options{
+		Algorithm: TokenBucket,
+		Rate:      5,
+		Period:    100 * time.Millisecond,
+		Burst:     1,
+	})
+
+	var done int32
+	task := Wrap(limiter, func(ctx context.Context) error {
+		atomic.AddInt32(&done, 1)
+		return nil
+	})
+
+	tasks := make(chan int, 10)
+	for i := 0; i < 10; i++ {
+		tasks <- i
+	}
+	close(tasks)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range tasks {
+				if err := task(context.Background()); err != nil {
+					t.Errorf("任务执行失败: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 每 20ms 放行一个，10个任务至少需要 180ms
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("任务未被限流，10个任务耗时: %v", elapsed)
+	}
+	if done != 10 {
+		t.Errorf("期望执行10个任务，实际: %d", done)
+	}
+}
@@ -0,0 +1,210 @@
+// Package ratelimit 提供基于 Redis 的分布式限流，多个实例共享同一个 key 上的配额。
+//
+// 支持令牌桶、滑动窗口日志和 GCRA 三种算法，均以 Lua 脚本原子执行，并以 Redis 的 TIME
+// 作为时钟，不受各实例之间时钟偏差的影响。典型用法：
+//
+//	limiter := ratelimit.NewClient(rdb).NewLimiter("ratelimit:httpbin.org", &ratelimit.Options{
+//		Rate:   2,
+//		Period: time.Second,
+//	})
+//	client := &http.Client{Transport: ratelimit.Transport(limiter, nil)}
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"goRedisLock/internal/randid"
+)
+
+// DefaultPeriod 未指定时限流的统计周期
+const DefaultPeriod = time.Second
+
+var (
+	// ErrBurstExceeded 单次请求的数量超过了限流器允许的突发上限，永远无法被放行
+	ErrBurstExceeded = errors.New("ratelimit: 请求数超过突发上限")
+	// ErrInvalidCount 请求数不大于 0
+	ErrInvalidCount = errors.New("ratelimit: 请求数必须大于 0")
+)
+
+// Algorithm 限流算法
+type Algorithm int
+
+const (
+	// GCRA 通用信元速率算法：按固定间隔放行，允许 Burst 个请求的突发，每个 key 只保存一个时间戳
+	GCRA Algorithm = iota
+	// TokenBucket 令牌桶：桶容量为 Burst，每个 Period 匀速补充 Rate 个令牌
+	TokenBucket
+	// SlidingWindowLog 滑动窗口日志：记录每个请求的时间，任意 Period 长的窗口内最多放行 Rate 个请求。
+	// 限制最精确，但每个请求占用一个有序集合成员，不使用 Burst
+	SlidingWindowLog
+)
+
+// String 返回算法名称
+func (a Algorithm) String() string {
+	switch a {
+	case GCRA:
+		return "gcra"
+	case TokenBucket:
+		return "token_bucket"
+	case SlidingWindowLog:
+		return "sliding_window_log"
+	default:
+		return fmt.Sprintf("Algorithm(%d)", int(a))
+	}
+}
+
+// Options 限流配置
+type Options struct {
+	// Algorithm 限流算法，默认 GCRA
+	Algorithm Algorithm
+	// Rate 每个 Period 内放行的请求数，必须大于 0
+	Rate int
+	// Period 统计周期，默认 DefaultPeriod
+	Period time.Duration
+	// Burst 允许的突发请求数，默认等于 Rate
+	Burst int
+}
+
+// withDefaults 返回补齐 Period 和 Burst 的限流配置副本，Rate 由 NewLimiter 校验
+func (o *Options) withDefaults() *Options {
+	var opts Options
+	if o != nil {
+		opts = *o
+	}
+	if opts.Period <= 0 {
+		opts.Period = DefaultPeriod
+	}
+	if opts.Burst <= 0 {
+		opts.Burst = opts.Rate
+	}
+	return &opts
+}
+
+// Client 创建限流器的客户端
+type Client struct {
+	rdb redis.Scripter
+}
+
+// NewClient 基于 Redis 连接创建限流客户端，单机、哨兵和集群连接都可以使用
+func NewClient(rdb redis.Scripter) *Client {
+	return &Client{rdb: rdb}
+}
+
+// NewLimiter 创建 key 上的限流器，使用相同 key 和配置的限流器（包括其他实例上的）共享配额。
+// opts.Rate 不大于 0 时 panic
+func (c *Client) NewLimiter(key string, opts *Options) *Limiter {
+	o := opts.withDefaults()
+	if o.Rate <= 0 {
+		panic("ratelimit: Options.Rate 必须大于 0")
+	}
+	return &Limiter{client: c, key: key, opts: *o}
+}
+
+// Result 一次限流判断的结果
+type Result struct {
+	// Allowed 是否放行
+	Allowed bool
+	// Remaining 判断之后还能立即放行的请求数
+	Remaining int
+	// RetryAfter 未放行时，至少等待多久后重试才可能放行；放行时为 0
+	RetryAfter time.Duration
+	// ResetAfter 多久后配额完全恢复
+	ResetAfter time.Duration
+}
+
+// Limiter key 上的分布式限流器
+type Limiter struct {
+	client *Client
+	key    string
+	opts   Options
+}
+
+// Key 返回限流器的 key
+func (l *Limiter) Key() string {
+	return l.key
+}
+
+// Allow 判断是否放行一个请求
+func (l *Limiter) Allow(ctx context.Context) (*Result, error) {
+	return l.AllowN(ctx, 1)
+}
+
+// AllowN 判断是否同时放行 n 个请求，只会全部放行或全部拒绝。
+// n 不大于 0 时返回 ErrInvalidCount，超过突发上限（滑动窗口日志为 Rate）时返回 ErrBurstExceeded
+func (l *Limiter) AllowN(ctx context.Context, n int) (*Result, error) {
+	if n <= 0 {
+		return nil, ErrInvalidCount
+	}
+	if n > l.burst() {
+		return nil, ErrBurstExceeded
+	}
+
+	o := l.opts
+	keys := []string{l.key}
+	var cmd *redis.Cmd
+	switch o.Algorithm {
+	case GCRA:
+		interval := float64(o.Period) / float64(o.Rate) / float64(time.Millisecond)
+		cmd = gcraScript.Run(ctx, l.client.rdb, keys, interval, o.Burst, n)
+	case TokenBucket:
+		rate := float64(o.Rate) / float64(o.Period) * float64(time.Millisecond)
+		cmd = tokenBucketScript.Run(ctx, l.client.rdb, keys, o.Burst, rate, n)
+	case SlidingWindowLog:
+		cmd = slidingWindowLogScript.Run(ctx, l.client.rdb, keys, o.Rate, o.Period.Milliseconds(), n, randid.New())
+	default:
+		return nil, fmt.Errorf("ratelimit: 未知的限流算法 %v", o.Algorithm)
+	}
+
+	values, err := cmd.Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	return &Result{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		ResetAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
+
+// Wait 阻塞直到放行一个请求或 ctx 结束，ctx 结束时返回 ctx 的错误
+func (l *Limiter) Wait(ctx context.Context) error {
+	for {
+		res, err := l.Allow(ctx)
+		if err != nil {
+			return err
+		}
+		if res.Allowed {
+			return nil
+		}
+
+		wait := res.RetryAfter
+		if wait <= 0 {
+			wait = time.Millisecond
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			// 等到截止时间也无法放行，直接返回
+			return context.DeadlineExceeded
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// burst 单次请求允许的最大数量
+func (l *Limiter) burst() int {
+	if l.opts.Algorithm == SlidingWindowLog {
+		return l.opts.Rate
+	}
+	return l.opts.Burst
+}
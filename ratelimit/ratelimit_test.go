@@ -0,0 +1,168 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"goRedisLock/internal/redistest"
+)
+
+var algorithms = []Algorithm{GCRA, TokenBucket, SlidingWindowLog}
+
+// testKey 每种算法使用各自的 key：三种算法保存的数据类型不同，在真实 Redis 上共用 key 会互相干扰
+func testKey(alg Algorithm) string {
+	return "test_ratelimit_" + alg.String()
+}
+
+func TestLimiterAllow(t *testing.T) {
+	for _, alg := range algorithms {
+		t.Run(alg.String(), func(t *testing.T) {
+			ctx := context.Background()
+			client := NewClient(redistest.New(t, testKey(alg)).Client)
+			limiter := client.NewLimiter(testKey(alg), &Options{Algorithm: alg, Rate: 5, Period: 500 * time.Millisecond})
+
+			// 突发上限内全部放行
+			for i := 0; i < 5; i++ {
+				res, err := limiter.Allow(ctx)
+				if err != nil {
+					t.Fatalf("限流判断失败: %v", err)
+				}
+				if !res.Allowed {
+					t.Fatalf("第%d个请求应被放行: %+v", i+1, res)
+				}
+				if res.Remaining != 4-i {
+					t.Errorf("第%d个请求后剩余配额应为%d，实际: %d", i+1, 4-i, res.Remaining)
+				}
+			}
+
+			res, err := limiter.Allow(ctx)
+			if err != nil {
+				t.Fatalf("限流判断失败: %v", err)
+			}
+			if res.Allowed || res.RetryAfter <= 0 || res.RetryAfter > 500*time.Millisecond {
+				t.Fatalf("超过配额应被拒绝并给出重试时间: %+v", res)
+			}
+
+			// 等待 RetryAfter 后应再次放行
+			time.Sleep(res.RetryAfter)
+			if res, err := limiter.Allow(ctx); err != nil || !res.Allowed {
+				t.Errorf("等待 RetryAfter 后应被放行: %+v, %v", res, err)
+			}
+		})
+	}
+}
+
+func TestLimiterAllowN(t *testing.T) {
+	for _, alg := range algorithms {
+		t.Run(alg.String(), func(t *testing.T) {
+			ctx := context.Background()
+			client := NewClient(redistest.New(t, testKey(alg)).Client)
+			limiter := client.NewLimiter(testKey(alg), &Options{Algorithm: alg, Rate: 5, Period: time.Minute})
+
+			if _, err := limiter.AllowN(ctx, 6); !errors.Is(err, ErrBurstExceeded) {
+				t.Errorf("期望 ErrBurstExceeded，实际: %v", err)
+			}
+			// 请求数不大于 0 时不能归还配额
+			for _, n := range []int{0, -100} {
+				if _, err := limiter.AllowN(ctx, n); !errors.Is(err, ErrInvalidCount) {
+					t.Errorf("AllowN(%d) 期望 ErrInvalidCount，实际: %v", n, err)
+				}
+			}
+			if res, err := limiter.AllowN(ctx, 3); err != nil || !res.Allowed || res.Remaining != 2 {
+				t.Fatalf("应放行3个请求: %+v, %v", res, err)
+			}
+			// 剩余配额不足时全部拒绝，不消耗配额
+			if res, err := limiter.AllowN(ctx, 3); err != nil || res.Allowed {
+				t.Fatalf("配额不足时应拒绝: %+v, %v", res, err)
+			}
+			if res, err := limiter.AllowN(ctx, 2); err != nil || !res.Allowed || res.Remaining != 0 {
+				t.Errorf("应放行剩余的2个请求: %+v, %v", res, err)
+			}
+		})
+	}
+}
+
+// 多个实例上相同 key 的限流器共享配额
+func TestLimiterShared(t *testing.T) {
+	for _, alg := range algorithms {
+		t.Run(alg.String(), func(t *testing.T) {
+			ctx := context.Background()
+			server := redistest.New(t, testKey(alg))
+			opts := &Options{Algorithm: alg, Rate: 10, Period: time.Minute}
+			limiters := []*Limiter{
+				NewClient(server.Client).NewLimiter(testKey(alg), opts),
+				NewClient(server.Client).NewLimiter(testKey(alg), opts),
+			}
+
+			allowed := 0
+			for i := 0; i < 30; i++ {
+				res, err := limiters[i%2].Allow(ctx)
+				if err != nil {
+					t.Fatalf("限流判断失败: %v", err)
+				}
+				if res.Allowed {
+					allowed++
+				}
+			}
+			if allowed != 10 {
+				t.Errorf("期望共放行10个请求，实际: %d", allowed)
+			}
+		})
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	for _, alg := range algorithms {
+		t.Run(alg.String(), func(t *testing.T) {
+			ctx := context.Background()
+			client := NewClient(redistest.New(t, testKey(alg)).Client)
+			limiter := client.NewLimiter(testKey(alg), &Options{Algorithm: alg, Rate: 2, Period: 200 * time.Millisecond})
+
+			start := time.Now()
+			for i := 0; i < 4; i++ {
+				if err := limiter.Wait(ctx); err != nil {
+					t.Fatalf("等待放行失败: %v", err)
+				}
+			}
+			// 前2个立即放行，后2个需要等待配额恢复
+			if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+				t.Errorf("超过配额的请求应被阻塞，实际耗时: %v", elapsed)
+			}
+
+			// 截止时间前无法放行时立即返回
+			ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+			defer cancel()
+			for i := 0; i < 2; i++ {
+				limiter.Allow(ctx)
+			}
+			if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("期望 context.DeadlineExceeded，实际: %v", err)
+			}
+		})
+	}
+}
+
+// 绕过 AllowN 直接执行脚本时，脚本同样拒绝不大于 0 的请求数
+func TestScriptsRejectInvalidCount(t *testing.T) {
+	ctx := context.Background()
+	rdb := redistest.New(t, "test_ratelimit_script").Client
+
+	scripts := map[string]func() error{
+		"gcra": func() error {
+			return gcraScript.Run(ctx, rdb, []string{"test_ratelimit_script"}, 100, 5, -100).Err()
+		},
+		"token_bucket": func() error {
+			return tokenBucketScript.Run(ctx, rdb, []string{"test_ratelimit_script"}, 5, 0.01, -100).Err()
+		},
+		"sliding_window_log": func() error {
+			return slidingWindowLogScript.Run(ctx, rdb, []string{"test_ratelimit_script"}, 5, 1000, -100, "req").Err()
+		},
+	}
+	for name, run := range scripts {
+		if err := run(); err == nil {
+			t.Errorf("%s: 请求数为负时脚本应返回错误", name)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import "github.com/go-redis/redis/v8"
+
+// 所有脚本的 ARGV[3] 都是请求数，不大于 0 时拒绝执行，避免归还配额；
+// 都返回 {是否放行, 剩余可放行数, 重试等待毫秒数, 完全恢复毫秒数}，以 Redis 的 TIME 作为当前时间（毫秒）
+const scriptNow = `
+	if (tonumber(ARGV[3]) or 0) < 1 then
+		return redis.error_reply("ratelimit: invalid count")
+	end
+	local t = redis.call("time")
+	local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+`
+
+// GCRA：KEYS[1] 保存理论到达时间（TAT），ARGV: 放行间隔（毫秒，可为小数）、突发上限、请求数。
+// 请求在 TAT - 间隔*突发上限 之后到达即可放行，放行后 TAT 前移 间隔*请求数
+var gcraScript = redis.NewScript(scriptNow + `
+	local interval = tonumber(ARGV[1])
+	local burst_offset = interval * tonumber(ARGV[2])
+	local n = tonumber(ARGV[3])
+
+	local tat = tonumber(redis.call("get", KEYS[1])) or now
+	if tat < now then
+		tat = now
+	end
+
+	local new_tat = tat + interval * n
+	local diff = now - (new_tat - burst_offset)
+	if diff < 0 then
+		local remaining = math.floor((now - (tat - burst_offset)) / interval)
+		return {0, remaining, math.ceil(-diff), math.ceil(tat - now)}
+	end
+
+	local reset = math.ceil(new_tat - now)
+	redis.call("set", KEYS[1], tostring(new_tat), "px", math.max(reset, 1))
+	return {1, math.floor(diff / interval), 0, reset}
+`)
+
+// 令牌桶：KEYS[1] 哈希保存令牌数和上次补充时间，ARGV: 桶容量、每毫秒补充的令牌数（可为小数）、请求数
+var tokenBucketScript = redis.NewScript(scriptNow + `
+	local capacity = tonumber(ARGV[1])
+	local rate = tonumber(ARGV[2])
+	local n = tonumber(ARGV[3])
+
+	local state = redis.call("hmget", KEYS[1], "tokens", "ts")
+	local tokens = tonumber(state[1]) or capacity
+	local ts = tonumber(state[2]) or now
+	if now > ts then
+		tokens = tokens + (now - ts) * rate
+	end
+	-- 令牌数始终限制在 [0, 桶容量] 内，容量调小或状态被改写后也不会超发
+	tokens = math.max(0, math.min(capacity, tokens))
+
+	local allowed, retry = 0, 0
+	if tokens >= n then
+		tokens = tokens - n
+		allowed = 1
+	else
+		retry = math.ceil((n - tokens) / rate)
+	end
+
+	local reset = math.ceil((capacity - tokens) / rate)
+	redis.call("hset", KEYS[1], "tokens", tostring(tokens), "ts", now)
+	redis.call("pexpire", KEYS[1], math.max(reset, 1))
+	return {allowed, math.floor(tokens), retry, reset}
+`)
+
+// 滑动窗口日志：KEYS[1] 有序集合记录窗口内每个请求的时间，
+// ARGV: 窗口内上限、窗口长度（毫秒）、请求数、本次请求的唯一标识
+var slidingWindowLogScript = redis.NewScript(scriptNow + `
+	local limit = tonumber(ARGV[1])
+	local window = tonumber(ARGV[2])
+	local n = tonumber(ARGV[3])
+
+	redis.call("zremrangebyscore", KEYS[1], "-inf", now - window)
+	local count = redis.call("zcard", KEYS[1])
+
+	if count + n > limit then
+		-- 等到足够多的旧请求移出窗口
+		local oldest = redis.call("zrange", KEYS[1], count + n - limit - 1, count + n - limit - 1, "withscores")
+		local newest = redis.call("zrange", KEYS[1], -1, -1, "withscores")
+		return {0, limit - count, tonumber(oldest[2]) + window - now, tonumber(newest[2]) + window - now}
+	end
+
+	for i = 1, n do
+		redis.call("zadd", KEYS[1], now, ARGV[4] .. ":" .. i)
+	end
+	redis.call("pexpire", KEYS[1], window)
+	return {1, limit - count - n, 0, window}
+`)